@@ -4,44 +4,68 @@ import (
 	"ai-browser-agent/internal/agent"
 	"ai-browser-agent/internal/browser"
 	"ai-browser-agent/internal/config"
-	"ai-browser-agent/internal/core"
 	"ai-browser-agent/internal/executor"
 	"ai-browser-agent/internal/interpreter"
 	"ai-browser-agent/internal/llm"
 	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
-	"github.com/playwright-community/playwright-go"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 )
 
 func main() {
+	schemaPath := flag.String("schema", "", "JSON schema the final result data must match")
+	outPath := flag.String("out", "", "write the final result as JSON to this file")
+	flag.Parse()
+
 	cfg, err := config.Load("config/local.yml")
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	llmClient, err := llm.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	br, err := browser.Launch(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer br.Close()
 
-	br.Page.SetDefaultTimeout(10000)
+	br.Active().SetDefaultTimeout(10000)
 
-	_, err = br.Page.Goto("https://example.com")
+	_, err = br.Active().Goto("https://example.com")
 	if err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		return
 	}
 
-	interp := interpreter.New(br.Page)
-	exec := executor.New(br.Page, interp)
+	interp := interpreter.New(br.Active(), cfg.Browser.Snapshot)
+	exec := executor.New(cfg, br, interp)
 
-	llmClient := llm.NewZai(cfg)
+	ag := agent.New(cfg, llmClient, interp, exec, br)
+	ag.OnStep = printStep
 
-	ag := agent.New(llmClient, interp)
+	if *schemaPath != "" {
+		raw, err := os.ReadFile(*schemaPath)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		if err = ag.SetResultSchema(raw); err != nil {
+			log.Print(err)
+			return
+		}
+	}
 
 	fmt.Println("Введите цель для агента (нажмите Enter после ввода):")
 	scanner := bufio.NewScanner(os.Stdin)
@@ -49,68 +73,103 @@ func main() {
 	goal := strings.TrimSpace(scanner.Text())
 
 	if goal == "" {
-		log.Fatal("Цель не введена. Завершение.")
+		log.Print("Цель не введена. Завершение.")
+		return
 	}
 
-	fmt.Println("Цель получена. Агент начинает работу...")
-
-	for {
-		action, err := ag.Step(goal)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		fmt.Printf("→ %s\n", action.String())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		if action.Type == core.ActionDone {
-			break
-		}
-
-		if err = exec.Execute(action); err != nil {
-			fmt.Printf("! Ошибка выполнения действия: %v\n", err)
-		}
+	if cfg.Agent.TimeoutSec > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.Agent.TimeoutSec)*time.Second)
+		defer cancel()
+	}
 
-		if err == nil {
-			_ = br.Page.WaitForLoadState(playwright.PageWaitForLoadStateOptions{
-				State:   playwright.LoadStateDomcontentloaded,
-				Timeout: playwright.Float(10000),
-			})
-			time.Sleep(1200 * time.Millisecond)
+	// Closing the browser aborts any playwright call still in flight.
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			br.Close()
+		case <-finished:
 		}
+	}()
 
-		var observation string
+	fmt.Println("Цель получена. Агент начинает работу...")
 
-		if err != nil {
-			observation = fmt.Sprintf("ОШИБКА: %v", err)
-		} else {
-			currentURL := br.Page.URL()
-			currentTitle, _ := br.Page.Title()
-
-			visibleText, errTxt := br.Page.Locator("body").InnerText()
-			if errTxt == nil {
-				visibleText = strings.ReplaceAll(visibleText, "\n", " ")
-				visibleText = strings.TrimSpace(visibleText)
-				if len(visibleText) > 400 {
-					visibleText = visibleText[:350] + "... (обрезано)"
-				}
+	res := ag.Run(ctx, goal)
+	close(finished)
+	// From here on Ctrl-C ends the program as usual.
+	stop()
+
+	switch res.Status {
+	case agent.StatusDone:
+		printResult(res)
+		if *outPath != "" {
+			if err := writeResult(*outPath, res); err != nil {
+				fmt.Printf("! Не удалось записать результат: %v\n", err)
 			} else {
-				visibleText = "(не удалось получить текст)"
+				fmt.Printf("Результат записан в %s\n", *outPath)
 			}
-
-			observation = fmt.Sprintf(
-				"Действие выполнено.\nURL: %s\nЗаголовок: %q\nВидимый текст (начало): %s",
-				currentURL, currentTitle, visibleText,
-			)
 		}
+	case agent.StatusMaxSteps:
+		fmt.Printf("■ Остановка: достигнут лимит шагов (%d)\n", res.Steps)
+	case agent.StatusBudget:
+		fmt.Printf("■ Остановка: %v\n", res.Err)
+	case agent.StatusError:
+		fmt.Printf("! Ошибка LLM [%s]: %v\n", llm.KindOf(res.Err), res.Err)
+	case agent.StatusCancelled:
+		fmt.Printf("■ Прервано: %v\n", res.Err)
+	}
 
-		ag.History = append(ag.History, fmt.Sprintf("%s → %s", action.String(), observation))
+	fmt.Printf("Итого: шагов %d, %s\n", res.Steps, res.Spent)
 
-		if len(ag.History) > 10 {
-			ag.History = ag.History[len(ag.History)-10:]
-		}
+	if res.Status == agent.StatusCancelled {
+		return
 	}
 
+	fmt.Printf("Финальный URL: %s\n", res.FinalURL)
 	fmt.Println("Нажмите Enter в терминале, чтобы закрыть браузер и завершить программу...")
 	var input string
 	fmt.Scanln(&input)
 }
+
+func printResult(res *agent.RunResult) {
+	if res.Answer != "" {
+		fmt.Printf("Ответ: %s\n", res.Answer)
+	}
+	if len(res.Data) > 0 {
+		fmt.Printf("Данные: %s\n", res.Data)
+	}
+	if res.Err != nil {
+		fmt.Printf("! %v\n", res.Err)
+	}
+}
+
+func writeResult(path string, res *agent.RunResult) error {
+	out := struct {
+		Status   agent.RunStatus `json:"status"`
+		Answer   string          `json:"answer,omitempty"`
+		Data     json.RawMessage `json:"data,omitempty"`
+		FinalURL string          `json:"final_url"`
+		Steps    int             `json:"steps"`
+	}{res.Status, res.Answer, res.Data, res.FinalURL, res.Steps}
+
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+func printStep(step int, resp *llm.Response, execErr error) {
+	log.Printf("LLM %s/%s: попыток %d, переспрашиваний %d, резервных переключений %d",
+		resp.Provider, resp.Model, resp.Attempts, resp.Reasks, resp.Failovers)
+
+	fmt.Printf("→ %s\n", resp.Action.String())
+
+	if execErr != nil {
+		fmt.Printf("! Ошибка выполнения действия: %v\n", execErr)
+	}
+}
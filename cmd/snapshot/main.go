@@ -0,0 +1,99 @@
+package main
+
+import (
+	"ai-browser-agent/internal/agent/promts"
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/config"
+	"ai-browser-agent/internal/interpreter"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// snapshot opens a page and prints what the JS walker and the
+// accessibility-tree backend see on it, side by side, to compare them.
+func main() {
+	url := flag.String("url", "", "page to snapshot")
+	configPath := flag.String("config", "config/local.yml", "config file")
+	flag.Parse()
+
+	if *url == "" {
+		log.Fatal("укажите -url")
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	br, err := browser.Launch(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer br.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	page := br.Active()
+	if _, err = page.Goto(*url); err != nil {
+		log.Print(err)
+		return
+	}
+	if err = br.Settle(ctx, page, ""); err != nil {
+		log.Print(err)
+		return
+	}
+
+	snapshots := map[string][]interpreter.Element{}
+	for _, mode := range []string{interpreter.SnapshotJS, interpreter.SnapshotAX} {
+		els, err := interpreter.New(page, mode).Snapshot(ctx)
+		if err != nil {
+			fmt.Printf("! %s: %v\n", mode, err)
+			continue
+		}
+		snapshots[mode] = els
+
+		fmt.Printf("=== %s: %d элементов ===\n%s\n", mode, len(els), promts.BuildSnapshotPrompt(els))
+	}
+
+	js, ax := snapshots[interpreter.SnapshotJS], snapshots[interpreter.SnapshotAX]
+	onlyJS, onlyAX := onlyIn(js, ax), onlyIn(ax, js)
+	fmt.Printf("=== сравнение по названию ===\n")
+	fmt.Printf("только в js (%d):\n%s", len(onlyJS), list(onlyJS))
+	fmt.Printf("только в ax (%d):\n%s", len(onlyAX), list(onlyAX))
+}
+
+// onlyIn returns names of elements of a that b has no element with. Roles
+// are not compared: the JS walker reports tag names where the
+// accessibility tree has ARIA roles.
+func onlyIn(a, b []interpreter.Element) []string {
+	seen := map[string]int{}
+	for _, el := range b {
+		seen[strings.ToLower(el.Name)]++
+	}
+
+	var out []string
+	for _, el := range a {
+		key := strings.ToLower(el.Name)
+		if seen[key] > 0 {
+			seen[key]--
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s %q", el.Role, el.Name))
+	}
+	return out
+}
+
+func list(items []string) string {
+	var sb strings.Builder
+	for _, it := range items {
+		sb.WriteString("  " + it + "\n")
+	}
+	return sb.String()
+}
@@ -4,6 +4,7 @@ go 1.25.6
 
 require (
 	github.com/joho/godotenv v1.5.1
+	github.com/playwright-community/playwright-go v0.5200.1
 	github.com/spf13/viper v1.21.0
 )
 
@@ -14,7 +15,6 @@ require (
 	github.com/go-stack/stack v1.8.1 // indirect
 	github.com/go-viper/mapstructure/v2 v2.4.0 // indirect
 	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
-	github.com/playwright-community/playwright-go v0.5200.1 // indirect
 	github.com/sagikazarmark/locafero v0.11.0 // indirect
 	github.com/sourcegraph/conc v0.3.1-0.20240121214520-5f936abd7ae8 // indirect
 	github.com/spf13/afero v1.15.0 // indirect
@@ -2,52 +2,202 @@ package agent
 
 import (
 	"ai-browser-agent/internal/agent/promts"
+	"context"
+	"errors"
 	"fmt"
-	"strings"
 
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/config"
 	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/executor"
 	"ai-browser-agent/internal/interpreter"
 	"ai-browser-agent/internal/llm"
+	"ai-browser-agent/internal/schema"
+
+	"github.com/playwright-community/playwright-go"
 )
 
 type Agent struct {
+	cfg     *config.Config
 	llm     llm.Client
 	i       *interpreter.Interpreter
-	History []string
+	exec    executor.Executor
+	session Session
+	History []core.StepRecord
+	Spent   Spend
+
+	// schema validates done.data; rawSchema is shown to the model verbatim.
+	schema    *schema.Schema
+	rawSchema string
+
+	// lastElements is the snapshot the current action was chosen from: the
+	// executor resolves targets against it, and the next Step diffs it.
+	lastElements []interpreter.Element
+	// view is the page and filter of lastElements shown to the model.
+	view elementsView
+
+	// OnStep, if set, is called by Run after each model decision with the
+	// error from executing it (nil for done or success).
+	OnStep func(step int, resp *llm.Response, execErr error)
+}
+
+// Session reports browser-level state that is not part of a page snapshot:
+// the active tab, open tabs, finished downloads and a pending dialog.
+// *browser.Browser implements it.
+type Session interface {
+	Active() playwright.Page
+	Tabs() []browser.Tab
+	TakeDownloads() []core.Download
+	PendingDialog() *core.Dialog
 }
 
-func New(llm llm.Client, i *interpreter.Interpreter) *Agent {
-	return &Agent{llm: llm, i: i}
+func New(cfg *config.Config, llm llm.Client, i *interpreter.Interpreter, exec executor.Executor, session Session) *Agent {
+	return &Agent{cfg: cfg, llm: llm, i: i, exec: exec, session: session}
 }
 
-func (a *Agent) Step(goal string) (*core.Action, error) {
-	elements, err := a.i.Snapshot()
+// SetResultSchema makes Run require done.data to match the JSON schema raw.
+func (a *Agent) SetResultSchema(raw []byte) error {
+	s, err := schema.Parse(raw)
 	if err != nil {
+		return err
+	}
+	a.schema, a.rawSchema = s, string(raw)
+	return nil
+}
+
+func (a *Agent) Step(ctx context.Context, goal string) (*llm.Response, error) {
+	if err := a.checkBudget(); err != nil {
 		return nil, err
 	}
 
-	if len(elements) == 0 {
-		return nil, fmt.Errorf("no elements")
+	// An open dialog blocks the page's scripts: a snapshot would hang, and
+	// the only useful action is to answer the dialog.
+	var dialog *core.Dialog
+	if a.session != nil {
+		dialog = a.session.PendingDialog()
+
+		// A popup may have opened or closed itself since the last action.
+		a.i.SetPage(a.session.Active())
+	}
+
+	pageStr := ""
+	if dialog != nil {
+		pageStr = "DIALOG (страница заблокирована, ответь действием dialog):\n" + promts.BuildDialogPrompt(dialog)
+	} else {
+		elements, err := a.i.Snapshot(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(elements) == 0 {
+			return nil, fmt.Errorf("no elements")
+		}
+
+		if n := len(a.History); n > 0 && a.lastElements != nil {
+			a.History[n-1].Observation.Diff = diffElements(a.lastElements, elements)
+		}
+		a.lastElements = elements
+
+		shown, view := pageElements(elements, goal, a.view, a.cfg.Agent.Memory.MaxPageElements)
+
+		pageStr = "SNAPSHOT:\n" + promts.BuildSnapshotPrompt(shown) + promts.BuildElementsNote(view)
+	}
+
+	historyStr := promts.BuildHistoryPrompt(a.recent())
+	if historyStr != "" {
+		historyStr += "\n"
+	}
+
+	tabsStr := ""
+	if a.session != nil && dialog == nil {
+		tabsStr = "TABS (* — активная):\n" + promts.BuildTabsPrompt(a.session.Tabs()) + "\n"
 	}
 
-	historyStr := ""
-	if len(a.History) > 0 {
-		historyStr = "ПРЕДЫДУЩИЕ ДЕЙСТВИЯ И РЕЗУЛЬТАТЫ (ОБЯЗАТЕЛЬНО УЧТИ!):\\n" + strings.Join(a.History, "\n") + "\n\n"
-		historyStr += "НЕ ПОВТОРЯЙ успешные действия из списка выше. Если действие уже сделано успешно — переходи к следующему или завершай.\n"
+	schemaStr := ""
+	if a.rawSchema != "" {
+		schemaStr = "RESULT SCHEMA (поле data в done должно ему соответствовать):\n" + a.rawSchema + "\n\n"
 	}
 
+	// The system prompt goes separately: its wording depends on how the
+	// client asks for the action.
 	userPrompt := fmt.Sprintf(
-		"SYSTEM:\n%s\n\n%sGOAL:\n%s\n\nSNAPSHOT:\n%s",
-		promts.SystemPrompt,
+		"%sGOAL:\n%s\n\n%s%s%s",
 		historyStr,
 		goal,
-		promts.BuildSnapshotPrompt(elements),
+		schemaStr,
+		tabsStr,
+		pageStr,
 	)
 
-	action, err := a.llm.NextAction(userPrompt)
+	if e, ok := a.llm.(llm.Escalator); ok {
+		e.SetFailureStreak(a.failureStreak())
+	}
+
+	resp, err := a.llm.NextAction(ctx, userPrompt)
 	if err != nil {
+		var e *llm.Error
+		if errors.As(err, &e) {
+			a.charge(e.Spent())
+		}
 		return nil, err
 	}
 
-	return action, nil
+	a.charge(resp.Spent())
+
+	return resp, nil
+}
+
+// recent returns the last ShortTermSteps records shown to the model.
+func (a *Agent) recent() []core.StepRecord {
+	keep := a.cfg.Agent.Memory.ShortTermSteps
+	if keep <= 0 {
+		keep = defaultShortTermSteps
+	}
+	if len(a.History) > keep {
+		return a.History[len(a.History)-keep:]
+	}
+	return a.History
+}
+
+// failureStreak counts consecutive failed actions at the end of History.
+func (a *Agent) failureStreak() int {
+	n := 0
+	for i := len(a.History) - 1; i >= 0; i-- {
+		if a.History[i].Observation.Success {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// diffElements compares two snapshots by role and name.
+func diffElements(before, after []interpreter.Element) core.ElementDiff {
+	key := func(el interpreter.Element) string {
+		return el.Role + ": " + el.Name
+	}
+
+	seen := make(map[string]int, len(before))
+	for _, el := range before {
+		seen[key(el)]++
+	}
+
+	d := core.ElementDiff{Before: len(before), After: len(after)}
+	for _, el := range after {
+		k := key(el)
+		if seen[k] > 0 {
+			seen[k]--
+			continue
+		}
+		d.Added = append(d.Added, k)
+	}
+	for _, el := range before {
+		k := key(el)
+		if seen[k] > 0 {
+			seen[k]--
+			d.Removed = append(d.Removed, k)
+		}
+	}
+
+	return d
 }
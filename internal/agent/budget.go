@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"ai-browser-agent/internal/config"
+	"ai-browser-agent/internal/llm"
+)
+
+// ErrBudgetExceeded is returned by Step once the run has used up its token
+// or money budget. The wrapped message says which limit was hit.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Spend accumulates what a run has cost so far.
+type Spend struct {
+	Calls   int
+	Usage   llm.Usage
+	CostUSD float64
+}
+
+func (s Spend) String() string {
+	return fmt.Sprintf("вызовов LLM: %d, токенов: %d (prompt %d, completion %d), стоимость: $%.4f",
+		s.Calls, s.Usage.Total(), s.Usage.PromptTokens, s.Usage.CompletionTokens, s.CostUSD)
+}
+
+// charge counts one model call, pricing each model's tokens at its own rate.
+func (a *Agent) charge(spent []llm.ModelUsage) {
+	a.Spent.Calls++
+	for _, s := range spent {
+		a.Spent.Usage.Add(s.Usage)
+		a.Spent.CostUSD += cost(a.cfg.LLM.Pricing, s.Model, s.Usage)
+	}
+}
+
+// checkBudget returns a wrapped ErrBudgetExceeded once any limit is reached.
+func (a *Agent) checkBudget() error {
+	b := a.cfg.Agent.Budget
+
+	if b.MaxTokens > 0 && a.Spent.Usage.Total() >= b.MaxTokens {
+		return fmt.Errorf("%w: израсходовано %d из %d токенов", ErrBudgetExceeded, a.Spent.Usage.Total(), b.MaxTokens)
+	}
+	if b.MaxCostUSD > 0 && a.Spent.CostUSD >= b.MaxCostUSD {
+		return fmt.Errorf("%w: потрачено $%.4f из $%.4f", ErrBudgetExceeded, a.Spent.CostUSD, b.MaxCostUSD)
+	}
+
+	return nil
+}
+
+// cost prices usage by the first table entry matching model (case-insensitive).
+// Models missing from the table are free, which is right for local servers.
+func cost(prices []config.ModelPrice, model string, usage llm.Usage) float64 {
+	for _, p := range prices {
+		if strings.EqualFold(p.Model, model) {
+			return float64(usage.PromptTokens)*p.PromptPer1M/1e6 +
+				float64(usage.CompletionTokens)*p.CompletionPer1M/1e6
+		}
+	}
+	return 0
+}
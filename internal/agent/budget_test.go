@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/config"
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
+	"ai-browser-agent/internal/llm"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// dialogSession keeps a dialog pending, so Step asks the model without
+// taking a snapshot.
+type dialogSession struct{}
+
+func (dialogSession) Active() playwright.Page        { return nil }
+func (dialogSession) Tabs() []browser.Tab            { return nil }
+func (dialogSession) TakeDownloads() []core.Download { return nil }
+func (dialogSession) PendingDialog() *core.Dialog {
+	return &core.Dialog{Type: "alert", Message: "Привет"}
+}
+
+// modelClient answers as model, or fails with a server error when down.
+type modelClient struct {
+	model string
+	down  bool
+	usage llm.Usage
+}
+
+func (c *modelClient) NextAction(ctx context.Context, prompt string) (*llm.Response, error) {
+	if c.down {
+		return nil, &llm.Error{Kind: llm.KindServer, Provider: "test", Model: c.model, Attempts: 1, Usage: c.usage, Err: errors.New("503")}
+	}
+	return &llm.Response{Action: &core.Action{Type: core.ActionDialog}, Provider: "test", Model: c.model, Attempts: 1, Usage: c.usage}, nil
+}
+
+func budgetAgent(cfg *config.Config, client llm.Client) *Agent {
+	cfg.LLM.Pricing = []config.ModelPrice{
+		{Model: "Cheap", PromptPer1M: 1, CompletionPer1M: 2},
+		{Model: "Pricey", PromptPer1M: 10, CompletionPer1M: 20},
+	}
+	return New(cfg, client, interpreter.New(nil, ""), nil, dialogSession{})
+}
+
+func TestChargePerModelAfterFailover(t *testing.T) {
+	usage := llm.Usage{PromptTokens: 1000, CompletionTokens: 100}
+	chain := llm.NewChain(
+		&modelClient{model: "pricey", down: true, usage: usage},
+		&modelClient{model: "cheap", usage: usage},
+	)
+	a := budgetAgent(&config.Config{}, chain)
+
+	if _, err := a.Step(context.Background(), "цель"); err != nil {
+		t.Fatal(err)
+	}
+
+	// pricey: 1000*10/1e6 + 100*20/1e6; cheap: 1000*1/1e6 + 100*2/1e6.
+	want := 0.012 + 0.0012
+	if math.Abs(a.Spent.CostUSD-want) > 1e-9 {
+		t.Errorf("cost = %.6f, want %.6f", a.Spent.CostUSD, want)
+	}
+	if a.Spent.Calls != 1 || a.Spent.Usage.Total() != 2200 {
+		t.Errorf("spent = %+v, want 1 call and 2200 tokens", a.Spent)
+	}
+}
+
+func TestChargeFailedCall(t *testing.T) {
+	usage := llm.Usage{PromptTokens: 1000, CompletionTokens: 100}
+	a := budgetAgent(&config.Config{}, &modelClient{model: "pricey", down: true, usage: usage})
+
+	if _, err := a.Step(context.Background(), "цель"); llm.KindOf(err) != llm.KindServer {
+		t.Fatalf("error = %v, want a server error", err)
+	}
+	if math.Abs(a.Spent.CostUSD-0.012) > 1e-9 || a.Spent.Usage.Total() != 1100 {
+		t.Errorf("spent = %+v, want the failed call's tokens charged", a.Spent)
+	}
+}
+
+func TestBudgetStopsSteps(t *testing.T) {
+	usage := llm.Usage{PromptTokens: 1000, CompletionTokens: 100}
+
+	tests := []struct {
+		name   string
+		budget config.BudgetConfig
+		steps  int // steps allowed before the budget runs out
+	}{
+		{"tokens", config.BudgetConfig{MaxTokens: 2500}, 3},
+		{"cost", config.BudgetConfig{MaxCostUSD: 0.003}, 3},
+		{"cost exact", config.BudgetConfig{MaxCostUSD: 0.0024}, 2},
+		{"unlimited", config.BudgetConfig{}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Agent.Budget = tt.budget
+			a := budgetAgent(cfg, &modelClient{model: "cheap", usage: usage})
+
+			for i := 0; i < 5; i++ {
+				_, err := a.Step(context.Background(), "цель")
+				if i < tt.steps {
+					if err != nil {
+						t.Fatalf("step %d: %v", i+1, err)
+					}
+					continue
+				}
+				if !errors.Is(err, ErrBudgetExceeded) {
+					t.Fatalf("step %d: error = %v, want ErrBudgetExceeded", i+1, err)
+				}
+				if a.Spent.Calls != tt.steps {
+					t.Errorf("calls = %d, want %d: a call was made over budget", a.Spent.Calls, tt.steps)
+				}
+				return
+			}
+		})
+	}
+}
+
+func TestCost(t *testing.T) {
+	prices := []config.ModelPrice{{Model: "GLM-4.6", PromptPer1M: 0.6, CompletionPer1M: 2.2}}
+	usage := llm.Usage{PromptTokens: 1_000_000, CompletionTokens: 500_000}
+
+	if got := cost(prices, "glm-4.6", usage); math.Abs(got-1.7) > 1e-9 {
+		t.Errorf("cost = %v, want 1.7", got)
+	}
+	if got := cost(prices, "llama3", usage); got != 0 {
+		t.Errorf("unpriced model cost = %v, want 0", got)
+	}
+}
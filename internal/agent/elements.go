@@ -0,0 +1,220 @@
+package agent
+
+import (
+	"ai-browser-agent/internal/agent/promts"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
+)
+
+const defaultMaxPageElements = 80
+
+// elementsView is the part of the snapshot the model asked for with the
+// elements action. The zero value is the first page, unfiltered; it is
+// reset after every action that may change the page.
+type elementsView struct {
+	Page  int
+	Query string
+}
+
+// roleWeights rank elements the model acts on most: fields first, then
+// controls, then links. Other roles get 0.3.
+var roleWeights = map[string]float64{
+	"input": 1, "textarea": 1, "select": 1, "textbox": 1, "searchbox": 1,
+	"combobox": 1, "listbox": 1, "spinbutton": 1, "slider": 1,
+	"button": 0.7, "checkbox": 0.7, "radio": 0.7, "switch": 0.7,
+	"tab": 0.7, "menuitem": 0.7, "option": 0.6,
+	"a": 0.5, "link": 0.5,
+}
+
+// pageElements ranks elements by relevance to goal, viewport proximity and
+// role, keeps those matching view.Query and returns page view.Page of at
+// most max of them in document order. Indices are left as in the snapshot,
+// so targets still resolve against the full list.
+func pageElements(elements []interpreter.Element, goal string, view elementsView, max int) ([]interpreter.Element, promts.ElementsView) {
+	if max <= 0 {
+		max = defaultMaxPageElements
+	}
+
+	matched := filterElements(elements, view.Query)
+	info := promts.ElementsView{Total: len(elements), Matched: len(matched), Query: view.Query}
+	if len(matched) == 0 {
+		return nil, info
+	}
+
+	goalWords := words(goal)
+	scores := make(map[int]float64, len(matched))
+	for _, el := range matched {
+		scores[el.Index] = score(el, goalWords)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return scores[matched[i].Index] > scores[matched[j].Index]
+	})
+
+	info.Pages = (len(matched) + max - 1) / max
+	info.Page = view.Page
+	if info.Page < 1 {
+		info.Page = 1
+	}
+	if info.Page > info.Pages {
+		info.Page = info.Pages
+	}
+
+	from := (info.Page - 1) * max
+	to := from + max
+	if to > len(matched) {
+		to = len(matched)
+	}
+	shown := matched[from:to]
+	sort.Slice(shown, func(i, j int) bool { return shown[i].Index < shown[j].Index })
+
+	info.Shown = len(shown)
+	return shown, info
+}
+
+// filterElements returns a copy of the elements whose text contains every
+// word of query; all of them for an empty query.
+func filterElements(elements []interpreter.Element, query string) []interpreter.Element {
+	terms := strings.Fields(strings.ToLower(query))
+
+	out := make([]interpreter.Element, 0, len(elements))
+	for _, el := range elements {
+		text := elementText(el)
+		ok := true
+		for _, t := range terms {
+			if !strings.Contains(text, t) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			out = append(out, el)
+		}
+	}
+	return out
+}
+
+// score weighs goal relevance highest: an element sharing words with the
+// goal beats a visible one that does not.
+func score(el interpreter.Element, goalWords []string) float64 {
+	s := 3 * relevance(words(elementText(el)), goalWords)
+
+	switch {
+	case el.InViewport:
+		s += 1
+	default:
+		// One screen away halves the bonus.
+		s += 1 / (1 + float64(el.Distance)/900)
+	}
+
+	if w, ok := roleWeights[el.Role]; ok {
+		s += w
+	} else {
+		s += 0.3
+	}
+
+	if el.IsHidden {
+		s -= 2
+	}
+	if el.Disabled {
+		s -= 0.5
+	}
+	return s
+}
+
+// relevance is the share of goal words found among the element's words.
+func relevance(elWords, goalWords []string) float64 {
+	if len(goalWords) == 0 || len(elWords) == 0 {
+		return 0
+	}
+
+	found := 0
+	for _, g := range goalWords {
+		for _, w := range elWords {
+			if sameWord(g, w) {
+				found++
+				break
+			}
+		}
+	}
+	return float64(found) / float64(len(goalWords))
+}
+
+// sameWord compares words loosely enough for inflected forms ("айфон",
+// "айфона") to match: equal, or sharing a prefix of at least 4 letters
+// that covers all but the last 2 letters of the shorter word.
+func sameWord(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ra, rb := []rune(a), []rune(b)
+	shorter := len(ra)
+	if len(rb) < shorter {
+		shorter = len(rb)
+	}
+	if shorter < 4 {
+		return false
+	}
+
+	common := 0
+	for common < shorter && ra[common] == rb[common] {
+		common++
+	}
+	return common >= 4 && common >= shorter-2
+}
+
+// words splits text into lowercase words, dropping one- and two-letter
+// words other than numbers ("в", "на", but not "17").
+func words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	out := fields[:0]
+	for _, f := range fields {
+		if utf8.RuneCountInString(f) >= 3 || unicode.IsDigit([]rune(f)[0]) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// elementText is the lowercase text an element is matched by.
+func elementText(el interpreter.Element) string {
+	parts := []string{el.Name, el.Value, el.Role, el.Group}
+	for _, o := range el.Options {
+		parts = append(parts, o.Label)
+	}
+	return strings.ToLower(strings.Join(parts, " "))
+}
+
+// showElements switches the snapshot view for the next step. It fails when
+// the requested page or filter would show nothing, so the model is told
+// instead of getting an empty snapshot.
+func (a *Agent) showElements(action *core.Action) error {
+	max := a.cfg.Agent.Memory.MaxPageElements
+	if max <= 0 {
+		max = defaultMaxPageElements
+	}
+
+	matched := len(filterElements(a.lastElements, action.Query))
+	if matched == 0 {
+		return fmt.Errorf("нет элементов, содержащих %q (всего элементов: %d)", action.Query, len(a.lastElements))
+	}
+
+	page := action.Page
+	if page < 1 {
+		page = 1
+	}
+	if pages := (matched + max - 1) / max; page > pages {
+		return fmt.Errorf("страницы %d нет: подходящих элементов %d, страниц %d", page, matched, pages)
+	}
+
+	a.view = elementsView{Page: page, Query: action.Query}
+	return nil
+}
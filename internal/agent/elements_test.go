@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"ai-browser-agent/internal/config"
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
+)
+
+func indices(els []interpreter.Element) []int {
+	out := make([]int, 0, len(els))
+	for _, el := range els {
+		out = append(out, el.Index)
+	}
+	return out
+}
+
+func buttons(n int) []interpreter.Element {
+	els := make([]interpreter.Element, n)
+	for i := range els {
+		els[i] = interpreter.Element{Index: i, Role: "button", Name: fmt.Sprintf("Кнопка %d", i), InViewport: true}
+	}
+	return els
+}
+
+func TestPageElementsPages(t *testing.T) {
+	els := buttons(5)
+
+	tests := []struct {
+		page     int
+		wantPage int
+		want     []int
+	}{
+		{0, 1, []int{0, 1}},
+		{1, 1, []int{0, 1}},
+		{2, 2, []int{2, 3}},
+		{3, 3, []int{4}},
+		{10, 3, []int{4}},
+		{-1, 1, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		shown, view := pageElements(els, "", elementsView{Page: tt.page}, 2)
+		if got := indices(shown); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("page %d: shown %v, want %v", tt.page, got, tt.want)
+		}
+		if view.Page != tt.wantPage || view.Pages != 3 || view.Total != 5 || view.Matched != 5 || view.Shown != len(tt.want) {
+			t.Errorf("page %d: view %+v", tt.page, view)
+		}
+	}
+}
+
+func TestPageElementsQuery(t *testing.T) {
+	els := []interpreter.Element{
+		{Index: 0, Role: "a", Name: "Главная"},
+		{Index: 1, Role: "button", Name: "В корзину"},
+		{Index: 2, Role: "select", Name: "Сортировка", Options: []interpreter.Option{{Label: "По цене"}}},
+		{Index: 3, Role: "a", Name: "Корзина (2)"},
+		{Index: 4, Role: "textbox", Name: "Поиск", Value: "айфон 17"},
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"корзин", []int{1, 3}},
+		{"КОРЗИНА", []int{3}},
+		{"цене", []int{2}},
+		{"айфон", []int{4}},
+		{"в корзину", []int{1}},
+		{"button", []int{1}},
+		{"нет такого", []int{}},
+	}
+	for _, tt := range tests {
+		shown, view := pageElements(els, "", elementsView{Query: tt.query}, 10)
+		if got := indices(shown); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("query %q: shown %v, want %v", tt.query, got, tt.want)
+		}
+		if view.Matched != len(tt.want) || view.Total != len(els) || view.Query != tt.query {
+			t.Errorf("query %q: view %+v", tt.query, view)
+		}
+	}
+}
+
+func TestPageElementsRelevanceBeatsViewport(t *testing.T) {
+	els := []interpreter.Element{
+		{Index: 0, Role: "button", Name: "Войти", InViewport: true},
+		{Index: 1, Role: "a", Name: "Каталог", InViewport: true},
+		{Index: 2, Role: "a", Name: "Купить iPhone 17 Pro", Distance: 3000},
+		{Index: 3, Role: "button", Name: "Скрытая", InViewport: true, IsHidden: true},
+	}
+
+	shown, _ := pageElements(els, "купи iphone 17", elementsView{}, 1)
+	if got := indices(shown); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("shown %v, want the relevant link below the fold", got)
+	}
+
+	shown, _ = pageElements(els, "", elementsView{}, 2)
+	if got := indices(shown); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("without a goal shown %v, want the visible elements", got)
+	}
+}
+
+func TestPageElementsKeepsIndices(t *testing.T) {
+	els := buttons(6)
+	els[1].Name, els[4].Name = "Оформить заказ", "Заказ"
+
+	shown, _ := pageElements(els, "оформить заказ", elementsView{}, 3)
+	got := indices(shown)
+	if len(got) != 3 || got[1] != 1 || got[2] != 4 {
+		t.Fatalf("shown %v, want 1 and 4 in document order after one other", got)
+	}
+	for _, el := range shown {
+		if el.Name != els[el.Index].Name {
+			t.Errorf("element %d is %q, want %q", el.Index, el.Name, els[el.Index].Name)
+		}
+	}
+
+	// The caller's snapshot is left as it was.
+	if got := indices(els); !reflect.DeepEqual(got, []int{0, 1, 2, 3, 4, 5}) {
+		t.Errorf("snapshot reordered: %v", got)
+	}
+}
+
+func TestScore(t *testing.T) {
+	goal := words("найти дешёвый айфон")
+	base := interpreter.Element{Role: "button", Name: "Далее", InViewport: true}
+
+	lower := func(name string, change func(*interpreter.Element)) {
+		t.Helper()
+		el := base
+		change(&el)
+		if score(el, goal) >= score(base, goal) {
+			t.Errorf("%s: score %.2f, want below %.2f", name, score(el, goal), score(base, goal))
+		}
+	}
+	lower("below the fold", func(el *interpreter.Element) { el.InViewport, el.Distance = false, 900 })
+	lower("hidden", func(el *interpreter.Element) { el.IsHidden = true })
+	lower("disabled", func(el *interpreter.Element) { el.Disabled = true })
+	lower("plain role", func(el *interpreter.Element) { el.Role = "div" })
+
+	far := base
+	far.InViewport, far.Distance = false, 9000
+	relevant := far
+	relevant.Name = "Айфоны дешевле"
+	if score(relevant, goal) <= score(base, goal) {
+		t.Errorf("relevant element far below scored %.2f, visible irrelevant %.2f", score(relevant, goal), score(base, goal))
+	}
+}
+
+func TestSameWord(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"айфон", "айфон", true},
+		{"айфон", "айфона", true},
+		{"айфоны", "айфонов", true},
+		{"корзина", "корзину", true},
+		{"купить", "купите", true},
+		{"кот", "кота", false},
+		{"17", "17", true},
+		{"17", "170", false},
+		{"заказ", "заказать", true},
+		{"карта", "катер", false},
+		{"доставка", "достопримечательность", false},
+		{"pro", "product", false},
+	}
+	for _, tt := range tests {
+		if got := sameWord(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameWord(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestShowElements(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Agent.Memory.MaxPageElements = 2
+	a := &Agent{cfg: cfg, lastElements: buttons(5)}
+	a.lastElements[3].Name = "Корзина"
+
+	tests := []struct {
+		action  core.Action
+		want    elementsView
+		wantErr bool
+	}{
+		{core.Action{Page: 2}, elementsView{Page: 2}, false},
+		{core.Action{}, elementsView{Page: 1}, false},
+		{core.Action{Page: 3}, elementsView{Page: 3}, false},
+		{core.Action{Page: 4}, elementsView{}, true},
+		{core.Action{Query: "корзина"}, elementsView{Page: 1, Query: "корзина"}, false},
+		{core.Action{Query: "корзина", Page: 2}, elementsView{}, true},
+		{core.Action{Query: "нет такого"}, elementsView{}, true},
+	}
+	for _, tt := range tests {
+		a.view = elementsView{}
+		err := a.showElements(&tt.action)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("showElements(%+v) error = %v, want error %v", tt.action, err, tt.wantErr)
+			continue
+		}
+		if a.view != tt.want {
+			t.Errorf("showElements(%+v): view %+v, want %+v", tt.action, a.view, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package promts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"ai-browser-agent/internal/core"
+)
+
+// BuildHistoryPrompt renders the most recent records for the model. Actions
+// are shown as the JSON the model produced so it can match them against
+// rule 2 of the system prompt.
+func BuildHistoryPrompt(records []core.StepRecord) string {
+	if len(records) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("ПРЕДЫДУЩИЕ ДЕЙСТВИЯ И РЕЗУЛЬТАТЫ (ОБЯЗАТЕЛЬНО УЧТИ!):\n")
+
+	for _, r := range records {
+		action, _ := json.Marshal(r.Action)
+		sb.WriteString(fmt.Sprintf("#%d %s → ", r.Step, action))
+
+		obs := r.Observation
+		if !obs.Success {
+			sb.WriteString(fmt.Sprintf("ОШИБКА: %s\n", obs.Error))
+			writeBatch(&sb, obs.Batch)
+			writeDownloads(&sb, obs.Downloads)
+			writeDialog(&sb, obs.Dialog)
+			continue
+		}
+
+		if obs.Dialog != nil {
+			sb.WriteString("OK\n")
+			writeBatch(&sb, obs.Batch)
+			writeDownloads(&sb, obs.Downloads)
+			writeDialog(&sb, obs.Dialog)
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("OK\n   URL: %s\n   Заголовок: %q\n   Видимый текст (начало): %s\n", obs.URL, obs.Title, obs.Text))
+		if obs.Warning != "" {
+			sb.WriteString(fmt.Sprintf("   ВНИМАНИЕ: %s\n", obs.Warning))
+		}
+		writeBatch(&sb, obs.Batch)
+		if obs.Extracted != "" {
+			sb.WriteString(fmt.Sprintf("   Извлечено: %s\n", obs.Extracted))
+		}
+		if d := diffLine(obs.Diff); d != "" {
+			sb.WriteString("   Элементы: " + d + "\n")
+		}
+		writeDownloads(&sb, obs.Downloads)
+	}
+
+	sb.WriteString("\nНЕ ПОВТОРЯЙ успешные действия из списка выше. Если действие уже сделано успешно — переходи к следующему или завершай.\n")
+	return sb.String()
+}
+
+func writeBatch(sb *strings.Builder, b *core.BatchResult) {
+	if b == nil {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("   Пакет: выполнено %d из %d", b.Ran, b.Total))
+	if b.Stopped != "" {
+		sb.WriteString("; остальные пропущены: " + b.Stopped)
+	}
+	sb.WriteString("\n")
+}
+
+func writeDialog(sb *strings.Builder, d *core.Dialog) {
+	if d != nil {
+		sb.WriteString("   Открыт диалог: " + BuildDialogPrompt(d))
+	}
+}
+
+func writeDownloads(sb *strings.Builder, downloads []core.Download) {
+	for _, d := range downloads {
+		if d.Error != "" {
+			sb.WriteString(fmt.Sprintf("   Загрузка %q не сохранена: %s\n", d.Name, d.Error))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("   Скачан файл: %q, %d байт, %s\n", d.Name, d.Size, d.MIME))
+	}
+}
+
+func diffLine(d core.ElementDiff) string {
+	if d.Before == 0 && d.After == 0 {
+		return ""
+	}
+	if len(d.Added) == 0 && len(d.Removed) == 0 {
+		return fmt.Sprintf("без изменений (%d)", d.After)
+	}
+
+	line := fmt.Sprintf("%d → %d", d.Before, d.After)
+	if len(d.Added) > 0 {
+		line += fmt.Sprintf("; появились: %s", quoteList(d.Added, 5))
+	}
+	if len(d.Removed) > 0 {
+		line += fmt.Sprintf("; исчезли: %s", quoteList(d.Removed, 5))
+	}
+	return line
+}
+
+func quoteList(items []string, max int) string {
+	shown := items
+	if len(shown) > max {
+		shown = shown[:max]
+	}
+
+	quoted := make([]string, len(shown))
+	for i, s := range shown {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+
+	out := strings.Join(quoted, ", ")
+	if len(items) > max {
+		out += fmt.Sprintf(" и ещё %d", len(items)-max)
+	}
+	return out
+}
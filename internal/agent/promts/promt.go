@@ -4,13 +4,15 @@ import (
 	"fmt"
 	"strings"
 
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/core"
 	"ai-browser-agent/internal/interpreter"
 )
 
 func BuildSnapshotPrompt(elements []interpreter.Element) string {
 	var sb strings.Builder
-	sb.WriteString("Индекс | Селектор | Роль | Название | Disabled | InViewport\n")
-	sb.WriteString("------|----------|------|----------|----------|------------\n")
+	sb.WriteString("Индекс | Селектор | Роль | Название | Disabled | InViewport | Состояние\n")
+	sb.WriteString("------|----------|------|----------|----------|------------|----------\n")
 
 	for _, el := range elements {
 		name := strings.ReplaceAll(el.Name, "\n", " ")
@@ -20,19 +22,145 @@ func BuildSnapshotPrompt(elements []interpreter.Element) string {
 		}
 
 		selector := el.Selector
+		if selector == "" {
+			selector = "-"
+		}
 		if len(selector) > 60 {
 			selector = selector[:57] + "..."
 		}
 
 		sb.WriteString(fmt.Sprintf(
-			"%d | %s | %s | %q | %v | %v\n",
+			"%d | %s | %s | %q | %v | %v | %s\n",
 			el.Index,
 			selector,
 			el.Role,
 			name,
 			el.Disabled,
 			el.InViewport,
+			elementState(el),
 		))
 	}
 	return sb.String()
 }
+
+// ElementsView says which part of the page's elements a snapshot shows.
+type ElementsView struct {
+	// Total counts all elements of the page, Matched those passing Query.
+	Total, Matched int
+	Shown          int
+	Page, Pages    int
+	Query          string
+}
+
+// BuildElementsNote tells the model how many elements the snapshot leaves
+// out and how to see them; "" when it shows all of them.
+func BuildElementsNote(v ElementsView) string {
+	if v.Query == "" && v.Shown == v.Total {
+		return ""
+	}
+
+	var sb strings.Builder
+	if v.Query != "" {
+		sb.WriteString(fmt.Sprintf("Фильтр %q: подходят %d из %d элементов, показаны %d (страница %d из %d).", v.Query, v.Matched, v.Total, v.Shown, v.Page, v.Pages))
+	} else {
+		sb.WriteString(fmt.Sprintf("Показаны %d из %d элементов (страница %d из %d): самые близкие к цели, видимые и важные по роли; %d не показаны.", v.Shown, v.Total, v.Page, v.Pages, v.Total-v.Shown))
+	}
+	if v.Page < v.Pages {
+		sb.WriteString(fmt.Sprintf(" Следующая страница: {\"type\": \"elements\", \"page\": %d", v.Page+1))
+		if v.Query != "" {
+			sb.WriteString(fmt.Sprintf(", \"query\": %q", v.Query))
+		}
+		sb.WriteString("}.")
+	}
+	if v.Query != "" {
+		sb.WriteString(" Сбросить фильтр: {\"type\": \"elements\"}.")
+	} else {
+		sb.WriteString(" Найти по тексту: {\"type\": \"elements\", \"query\": \"<текст>\"}.")
+	}
+	return sb.String() + "\n"
+}
+
+// elementState describes checked/expanded/selected state, current value and
+// select options, and where the element sits (iframe, dialog, form...);
+// "" when there is nothing to say.
+func elementState(el interpreter.Element) string {
+	var parts []string
+	if len(el.Frame) > 0 {
+		parts = append(parts, "в iframe")
+	}
+	if el.Group != "" {
+		parts = append(parts, "в "+el.Group)
+	}
+	if el.Checked != nil {
+		parts = append(parts, flagState(*el.Checked, "checked", "unchecked"))
+	}
+	if el.Expanded != nil {
+		parts = append(parts, flagState(*el.Expanded, "expanded", "collapsed"))
+	}
+	if el.Selected != nil && *el.Selected {
+		parts = append(parts, "selected")
+	}
+	if el.Required {
+		parts = append(parts, "required")
+	}
+	if el.Value != "" && el.Value != el.Name {
+		parts = append(parts, fmt.Sprintf("value=%q", el.Value))
+	}
+	if len(el.Options) > 0 {
+		list := "опции: " + optionList(el.Options)
+		if el.OptionsTruncated {
+			list += " и другие (показаны не все, можно указать любую опцию списка)"
+		}
+		parts = append(parts, list)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func flagState(on bool, yes, no string) string {
+	if on {
+		return yes
+	}
+	return no
+}
+
+func optionList(opts []interpreter.Option) string {
+	parts := make([]string, 0, len(opts))
+	for _, o := range opts {
+		p := fmt.Sprintf("%q", o.Label)
+		// The accessibility tree does not expose option values.
+		if o.Value != "" && o.Value != o.Label {
+			p += fmt.Sprintf("(value=%q)", o.Value)
+		}
+		if o.Selected {
+			p += "*"
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// BuildDialogPrompt describes a pending JavaScript dialog in one line.
+func BuildDialogPrompt(d *core.Dialog) string {
+	line := fmt.Sprintf("%s %q", d.Type, d.Message)
+	if d.Type == "prompt" {
+		line += fmt.Sprintf(" (значение по умолчанию: %q)", d.DefaultValue)
+	}
+	return line + "\n"
+}
+
+// BuildTabsPrompt lists open tabs; the active one is marked with "*".
+func BuildTabsPrompt(tabs []browser.Tab) string {
+	var sb strings.Builder
+	for _, t := range tabs {
+		mark := " "
+		if t.Active {
+			mark = "*"
+		}
+		title := t.Title
+		if len(title) > 60 {
+			title = title[:57] + "..."
+		}
+		sb.WriteString(fmt.Sprintf("%s%d | %q | %s\n", mark, t.Index, title, t.URL))
+	}
+	return sb.String()
+}
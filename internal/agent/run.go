@@ -0,0 +1,246 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/executor"
+	"ai-browser-agent/internal/interpreter"
+	"ai-browser-agent/internal/llm"
+)
+
+// RunStatus tells why Run stopped.
+type RunStatus string
+
+const (
+	StatusDone      RunStatus = "done"
+	StatusMaxSteps  RunStatus = "max_steps"
+	StatusBudget    RunStatus = "budget"
+	StatusError     RunStatus = "error"
+	StatusCancelled RunStatus = "cancelled"
+)
+
+// RunResult summarizes a finished run.
+type RunResult struct {
+	Status   RunStatus
+	Steps    int
+	FinalURL string
+	Spent    Spend
+	// Answer and Data are the result carried by the final done action.
+	Answer string
+	Data   json.RawMessage
+	// History is the full, untrimmed step log of the run.
+	History []core.StepRecord
+	// Err is set for StatusError, StatusBudget and StatusCancelled, and for
+	// StatusDone when Data still failed schema validation after all re-asks.
+	Err error
+}
+
+const (
+	defaultShortTermSteps = 10
+	// maxResultReasks bounds how often a done with invalid data is rejected.
+	maxResultReasks = 2
+	extractMaxChars = 2000
+)
+
+// Run drives the Step → Execute → observe loop until the model reports done,
+// MaxSteps is reached, the budget runs out, ctx is cancelled or the model
+// call fails. Execution errors are fed back to the model, not returned.
+func (a *Agent) Run(ctx context.Context, goal string) *RunResult {
+	res := &RunResult{}
+	resultReasks := 0
+	defer func() {
+		res.Spent = a.Spent
+		res.History = a.History
+		res.FinalURL = a.i.URL()
+	}()
+
+	for {
+		if max := a.cfg.Agent.MaxSteps; max > 0 && res.Steps >= max {
+			res.Status = StatusMaxSteps
+			return res
+		}
+
+		resp, err := a.Step(ctx, goal)
+		if ctx.Err() != nil {
+			res.Status, res.Err = StatusCancelled, ctx.Err()
+			return res
+		}
+		if errors.Is(err, ErrBudgetExceeded) {
+			res.Status, res.Err = StatusBudget, err
+			return res
+		}
+		if err != nil {
+			res.Status, res.Err = StatusError, err
+			return res
+		}
+
+		res.Steps++
+		action := resp.Action
+
+		if action.Type == core.ActionDone {
+			err = a.checkResult(action)
+			a.notify(res.Steps, resp, err)
+
+			// Reject the result and let the model fix it on the next step.
+			if err != nil && resultReasks < maxResultReasks {
+				resultReasks++
+				a.record(res.Steps, resp, core.Observation{Error: err.Error()})
+				continue
+			}
+
+			res.Status, res.Err = StatusDone, err
+			res.Answer, res.Data = action.Answer, action.Data
+			return res
+		}
+
+		if action.Type == core.ActionExtract {
+			obs := a.extract(ctx, action)
+			if obs.Success {
+				a.notify(res.Steps, resp, nil)
+			} else {
+				a.notify(res.Steps, resp, errors.New(obs.Error))
+			}
+			a.record(res.Steps, resp, obs)
+			continue
+		}
+
+		if action.Type == core.ActionElements {
+			started := time.Now()
+			err = a.showElements(action)
+			a.notify(res.Steps, resp, err)
+			a.record(res.Steps, resp, a.observe(err, time.Since(started)))
+			continue
+		}
+
+		// The page may change, so the next snapshot starts from its first page.
+		a.view = elementsView{}
+
+		started := time.Now()
+		err = a.exec.Execute(ctx, action, a.lastElements)
+		if ctx.Err() != nil {
+			res.Status, res.Err = StatusCancelled, ctx.Err()
+			return res
+		}
+
+		var batch *core.BatchResult
+		if action.Type == core.ActionBatch {
+			batch, err = batchResult(action, err)
+		}
+
+		warning := ""
+		var unverified *executor.UnverifiedError
+		if errors.As(err, &unverified) {
+			warning, err = unverified.Msg, nil
+		}
+		a.notify(res.Steps, resp, err)
+
+		obs := a.observe(err, time.Since(started))
+		obs.Batch, obs.Warning = batch, warning
+		a.record(res.Steps, resp, obs)
+	}
+}
+
+// batchResult reports how far a batch got. A batch the executor stopped on
+// purpose (navigation, changed snapshot) is not an execution error.
+func batchResult(action *core.Action, err error) (*core.BatchResult, error) {
+	res := &core.BatchResult{Ran: len(action.Actions), Total: len(action.Actions)}
+
+	var be *executor.BatchError
+	if errors.As(err, &be) {
+		res.Ran, res.Stopped = be.Ran, be.Reason
+		return res, be.Err
+	}
+	if err != nil {
+		res.Ran = 0 // rejected before the first action
+	}
+	return res, err
+}
+
+func (a *Agent) record(step int, resp *llm.Response, obs core.Observation) {
+	a.History = append(a.History, core.StepRecord{
+		Step:        step,
+		Action:      *resp.Action,
+		Observation: obs,
+		Provider:    resp.Provider,
+		Model:       resp.Model,
+	})
+}
+
+// extract reads text from the target element (or the page) without touching
+// it, so the model can read values it then reports through done.
+func (a *Agent) extract(ctx context.Context, action *core.Action) core.Observation {
+	started := time.Now()
+
+	var el *interpreter.Element
+	if action.HasTarget() {
+		if action.Target >= len(a.lastElements) {
+			return core.Observation{Error: fmt.Sprintf("invalid target index: %d (elements: %d)", action.Target, len(a.lastElements))}
+		}
+		el = &a.lastElements[action.Target]
+	}
+
+	text, err := a.i.ExtractText(ctx, el, extractMaxChars)
+	if err != nil {
+		return core.Observation{Error: err.Error(), Duration: time.Since(started)}
+	}
+
+	obs := a.observe(nil, time.Since(started))
+	obs.Extracted = text
+	return obs
+}
+
+// checkResult validates the done payload against the result schema.
+func (a *Agent) checkResult(action *core.Action) error {
+	if a.schema == nil {
+		return nil
+	}
+	if len(action.Data) == 0 {
+		return fmt.Errorf("результат не принят: done должен содержать поле data по схеме RESULT SCHEMA")
+	}
+	if err := a.schema.Validate(action.Data); err != nil {
+		return fmt.Errorf("результат не принят: data не соответствует схеме: %w", err)
+	}
+	return nil
+}
+
+func (a *Agent) notify(step int, resp *llm.Response, execErr error) {
+	if a.OnStep != nil {
+		a.OnStep(step, resp, execErr)
+	}
+}
+
+// observe builds the observation for an executed action. The element diff
+// is filled in by the next Step, which takes the following snapshot anyway.
+func (a *Agent) observe(execErr error, took time.Duration) core.Observation {
+	var downloads []core.Download
+	var dialog *core.Dialog
+	if a.session != nil {
+		downloads = a.session.TakeDownloads()
+		dialog = a.session.PendingDialog()
+	}
+
+	if execErr != nil {
+		return core.Observation{Error: execErr.Error(), Downloads: downloads, Dialog: dialog, Duration: took}
+	}
+
+	// Reading the page would block until the dialog is answered.
+	if dialog != nil {
+		return core.Observation{Success: true, Downloads: downloads, Dialog: dialog, Duration: took}
+	}
+
+	state := a.i.PageState()
+
+	return core.Observation{
+		Success:   true,
+		URL:       state.URL,
+		Title:     state.Title,
+		Text:      state.Text,
+		Downloads: downloads,
+		Duration:  took,
+	}
+}
@@ -2,15 +2,36 @@ package browser
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"ai-browser-agent/internal/config"
+	"ai-browser-agent/internal/core"
 	"github.com/playwright-community/playwright-go"
 )
 
 type Browser struct {
 	PW      *playwright.Playwright
 	Context playwright.BrowserContext
-	Page    playwright.Page
+	// RunDir is created per launch; downloads never leave DownloadsDir inside it.
+	RunDir       string
+	DownloadsDir string
+
+	mu     sync.Mutex
+	pages  []playwright.Page
+	active playwright.Page
+
+	downloads []core.Download
+	started   int
+	dialog    playwright.Dialog
+	dialogs   chan struct{}
+	reserved  map[string]struct{}
+
+	settle config.SettleConfig
+
+	closeOnce sync.Once
 }
 
 func Launch(cfg *config.Config) (*Browser, error) {
@@ -18,6 +39,15 @@ func Launch(cfg *config.Config) (*Browser, error) {
 		return nil, fmt.Errorf("install playwright: %w", err)
 	}
 
+	runDir, err := newRunDir(cfg.Agent.RunsDir)
+	if err != nil {
+		return nil, err
+	}
+	downloadsDir := filepath.Join(runDir, "downloads")
+	if err = os.MkdirAll(downloadsDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create downloads dir: %w", err)
+	}
+
 	pw, err := playwright.Run()
 	if err != nil {
 		return nil, fmt.Errorf("run playwright: %w", err)
@@ -32,36 +62,84 @@ func Launch(cfg *config.Config) (*Browser, error) {
 				Width:  cfg.Browser.Viewport.Width,
 				Height: cfg.Browser.Viewport.Height,
 			},
-			Timeout: playwright.Float(float64(cfg.Browser.TimeoutMs)),
+			Timeout:         playwright.Float(float64(cfg.Browser.TimeoutMs)),
+			AcceptDownloads: playwright.Bool(true),
+			// Transfers in progress stay inside the run too; saveDownload
+			// then copies them into downloadsDir under a checked name.
+			DownloadsPath: playwright.String(filepath.Join(runDir, "incoming")),
 		},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("launch context: %w", err)
 	}
 
-	var page playwright.Page
+	b := &Browser{
+		PW:           pw,
+		Context:      context,
+		RunDir:       runDir,
+		DownloadsDir: downloadsDir,
+		reserved:     map[string]struct{}{},
+		dialogs:      make(chan struct{}, 1),
+		settle:       settleOptions(cfg.Browser.Settle),
+	}
+
+	context.OnPage(b.track)
+
+	if len(cfg.Browser.Permissions) > 0 {
+		if err = context.GrantPermissions(cfg.Browser.Permissions); err != nil {
+			return nil, fmt.Errorf("grant permissions: %w", err)
+		}
+	}
+
 	pages := context.Pages()
-	if len(pages) > 0 {
-		page = pages[0]
-	} else {
-		page, err = context.NewPage()
+	if len(pages) == 0 {
+		page, err := context.NewPage()
 		if err != nil {
 			return nil, fmt.Errorf("new page: %w", err)
 		}
+		pages = []playwright.Page{page}
 	}
 
-	return &Browser{
-		PW:      pw,
-		Context: context,
-		Page:    page,
-	}, nil
+	for _, p := range pages {
+		b.track(p)
+	}
+	if err = b.SwitchTab(0); err != nil {
+		return nil, err
+	}
+
+	return b, nil
 }
 
-func (b *Browser) Close() {
-	if b.Context != nil {
-		_ = b.Context.Close()
+// newRunDir creates a directory for this run under base, named by the
+// start time plus a random suffix so concurrent runs never share one.
+func newRunDir(base string) (string, error) {
+	if base == "" {
+		base = "./data/runs"
+	}
+
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return "", fmt.Errorf("run dir: %w", err)
 	}
-	if b.PW != nil {
-		_ = b.PW.Stop()
+	if err = os.MkdirAll(base, 0o755); err != nil {
+		return "", fmt.Errorf("create run dir: %w", err)
 	}
+	dir, err := os.MkdirTemp(base, time.Now().Format("20060102-150405")+"-")
+	if err != nil {
+		return "", fmt.Errorf("create run dir: %w", err)
+	}
+	return dir, nil
+}
+
+// Close shuts the context and the driver down. It is safe to call more than
+// once, e.g. from a signal handler and a deferred call.
+func (b *Browser) Close() {
+	b.closeOnce.Do(func() {
+		if b.Context != nil {
+			_ = b.Context.Close()
+		}
+		if b.PW != nil {
+			_ = b.PW.Stop()
+		}
+	})
 }
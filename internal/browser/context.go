@@ -0,0 +1,36 @@
+package browser
+
+import (
+	"context"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Timeout caps a playwright timeout (ms) by the time left until ctx's
+// deadline, so a wait never outlives the run.
+func Timeout(ctx context.Context, ms float64) *float64 {
+	if deadline, ok := ctx.Deadline(); ok {
+		left := float64(time.Until(deadline).Milliseconds())
+		if left < 1 {
+			left = 1
+		}
+		if left < ms {
+			ms = left
+		}
+	}
+	return playwright.Float(ms)
+}
+
+// Sleep pauses for d or until ctx is done, returning ctx.Err() in the latter case.
+func Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
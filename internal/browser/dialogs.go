@@ -0,0 +1,86 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"ai-browser-agent/internal/core"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// onDialog keeps alert/confirm/prompt/beforeunload dialogs open until the
+// model decides what to do. Having a listener stops Playwright from
+// dismissing them silently.
+func (b *Browser) onDialog(d playwright.Dialog) {
+	b.mu.Lock()
+	b.dialog = d
+	b.mu.Unlock()
+
+	select {
+	case b.dialogs <- struct{}{}:
+	default:
+	}
+}
+
+// UntilDialog runs action and returns early, with opened set, if a dialog
+// opens before it finishes. The dialog freezes the page, so an action that
+// opened one would otherwise hang until its timeout although it worked; it
+// completes in the background once the dialog is answered.
+func (b *Browser) UntilDialog(ctx context.Context, action func() error) (opened bool, err error) {
+	// Forget a signal left by a dialog that has been answered since.
+	select {
+	case <-b.dialogs:
+	default:
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- action()
+	}()
+
+	select {
+	case err = <-done:
+		return false, err
+	case <-b.dialogs:
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// PendingDialog describes the dialog waiting for an answer, or nil.
+func (b *Browser) PendingDialog() *core.Dialog {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.dialog == nil {
+		return nil
+	}
+
+	return &core.Dialog{
+		Type:         b.dialog.Type(),
+		Message:      b.dialog.Message(),
+		DefaultValue: b.dialog.DefaultValue(),
+	}
+}
+
+// HandleDialog accepts (with text for prompts) or dismisses the pending dialog.
+func (b *Browser) HandleDialog(accept bool, text string) error {
+	b.mu.Lock()
+	d := b.dialog
+	b.dialog = nil
+	b.mu.Unlock()
+
+	if d == nil {
+		return fmt.Errorf("нет открытого диалога")
+	}
+
+	if !accept {
+		return d.Dismiss()
+	}
+	if d.Type() == "prompt" {
+		return d.Accept(text)
+	}
+	return d.Accept()
+}
@@ -0,0 +1,138 @@
+package browser
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ai-browser-agent/internal/core"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// onDownload saves a finished download into the run's downloads folder and
+// queues it for the next observation. Saving waits for the transfer, so it
+// runs off the event goroutine.
+func (b *Browser) onDownload(d playwright.Download) {
+	b.mu.Lock()
+	b.started++
+	b.mu.Unlock()
+
+	go func() {
+		info, err := b.saveDownload(d)
+		if err != nil {
+			log.Printf("Не удалось сохранить загрузку %q: %v", d.SuggestedFilename(), err)
+			info = core.Download{Name: d.SuggestedFilename(), Error: err.Error()}
+		}
+
+		b.mu.Lock()
+		b.downloads = append(b.downloads, info)
+		b.mu.Unlock()
+	}()
+}
+
+// DownloadsStarted counts downloads the site has started during the run,
+// including ones still being saved.
+func (b *Browser) DownloadsStarted() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.started
+}
+
+func (b *Browser) saveDownload(d playwright.Download) (core.Download, error) {
+	path, err := b.downloadPath(d.SuggestedFilename())
+	if err != nil {
+		return core.Download{}, err
+	}
+
+	if err = d.SaveAs(path); err != nil {
+		return core.Download{}, err
+	}
+
+	st, err := os.Stat(path)
+	if err != nil {
+		return core.Download{}, err
+	}
+
+	return core.Download{
+		Name: filepath.Base(path),
+		Path: path,
+		Size: st.Size(),
+		MIME: detectMIME(path),
+	}, nil
+}
+
+// downloadPath picks a free file name inside DownloadsDir. The suggested
+// name comes from the site, so only its base is used and the result is
+// checked to stay inside the folder.
+func (b *Browser) downloadPath(suggested string) (string, error) {
+	name := filepath.Base(strings.ReplaceAll(suggested, "\\", "/"))
+	if name == "." || name == ".." || name == "/" || name == "" {
+		name = "download"
+	}
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i := 0; ; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s (%d)%s", stem, i, ext)
+		}
+
+		path := filepath.Join(b.DownloadsDir, candidate)
+		if !within(b.DownloadsDir, path) {
+			return "", fmt.Errorf("путь загрузки %q вне папки запуска", path)
+		}
+		if _, taken := b.reserved[path]; taken {
+			continue
+		}
+		// Lstat: a dangling link would be written through.
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			b.reserved[path] = struct{}{}
+			return path, nil
+		}
+	}
+}
+
+// TakeDownloads returns downloads finished since the previous call.
+func (b *Browser) TakeDownloads() []core.Download {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := b.downloads
+	b.downloads = nil
+	return out
+}
+
+// within reports whether path is dir itself or lies inside it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func detectMIME(path string) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	return http.DetectContentType(buf[:n])
+}
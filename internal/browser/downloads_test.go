@@ -0,0 +1,83 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadPath(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "taken.pdf"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(t.TempDir(), "missing"), filepath.Join(dir, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		suggested string
+		want      string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{"/etc/passwd", "passwd"},
+		{`..\..\evil.exe`, "evil.exe"},
+		{`C:\Users\me\file.txt`, "file.txt"},
+		{"..", "download"},
+		{"", "download"},
+		{"taken.pdf", "taken (1).pdf"},
+		{"link.txt", "link (1).txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.suggested, func(t *testing.T) {
+			b := &Browser{DownloadsDir: dir, reserved: map[string]struct{}{}}
+
+			got, err := b.downloadPath(tt.suggested)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(dir, tt.want); got != want {
+				t.Errorf("downloadPath(%q) = %s, want %s", tt.suggested, got, want)
+			}
+		})
+	}
+}
+
+func TestDownloadPathReserves(t *testing.T) {
+	b := &Browser{DownloadsDir: t.TempDir(), reserved: map[string]struct{}{}}
+
+	first, err := b.downloadPath("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := b.downloadPath("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Errorf("two downloads got the same path %s", first)
+	}
+}
+
+func TestWithin(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/run/downloads", true},
+		{"/run/downloads/a.txt", true},
+		{"/run/downloads/sub/a.txt", true},
+		{"/run/downloads/..a.txt", true},
+		{"/run/downloads/../a.txt", false},
+		{"/run", false},
+		{"/run/downloads2/a.txt", false},
+		{"/etc/passwd", false},
+		{"downloads/a.txt", false},
+	}
+	for _, tt := range tests {
+		if got := within("/run/downloads", tt.path); got != tt.want {
+			t.Errorf("within(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
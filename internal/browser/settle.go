@@ -0,0 +1,102 @@
+package browser
+
+import (
+	"context"
+	"time"
+
+	"ai-browser-agent/internal/config"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	defaultNetworkIdleMs = 3000
+	defaultQuietMs       = 300
+	defaultMaxSettleMs   = 5000
+	defaultMaxWaitSec    = 30
+)
+
+// settleOptions fills in defaults for zero fields of cfg.
+func settleOptions(cfg config.SettleConfig) config.SettleConfig {
+	if cfg.NetworkIdleMs <= 0 {
+		cfg.NetworkIdleMs = defaultNetworkIdleMs
+	}
+	if cfg.QuietMs <= 0 {
+		cfg.QuietMs = defaultQuietMs
+	}
+	if cfg.MaxMs <= 0 {
+		cfg.MaxMs = defaultMaxSettleMs
+	}
+	if cfg.MaxWaitSec <= 0 {
+		cfg.MaxWaitSec = defaultMaxWaitSec
+	}
+	return cfg
+}
+
+// quietScript records the time of the last DOM mutation so that
+// waitQuietScript can poll for a quiet period without holding the page.
+const quietScript = `() => {
+    window.__agentLastMutation = performance.now();
+    if (window.__agentObserver) return;
+    window.__agentObserver = new MutationObserver(() => {
+        window.__agentLastMutation = performance.now();
+    });
+    window.__agentObserver.observe(document, {
+        subtree: true, childList: true, attributes: true, characterData: true
+    });
+}`
+
+const waitQuietScript = `(quiet) =>
+    window.__agentLastMutation === undefined ||
+    performance.now() - window.__agentLastMutation >= quiet`
+
+// Settle waits until the page stops changing after an action: the
+// navigation started by it (if the URL differs from before) has loaded,
+// the network is idle and the DOM has had no mutations for QuietMs. Each
+// phase is capped, so long-polling pages and endless animations only cost
+// the cap, not a failure.
+func (b *Browser) Settle(ctx context.Context, page playwright.Page, before string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if b.PendingDialog() != nil {
+		return nil
+	}
+
+	started := time.Now()
+	max := time.Duration(b.settle.MaxMs) * time.Millisecond
+	left := func() float64 {
+		return float64((max - time.Since(started)).Milliseconds())
+	}
+
+	if ms := left(); ms > 0 && page.URL() != before {
+		_ = page.WaitForLoadState(playwright.PageWaitForLoadStateOptions{
+			State:   playwright.LoadStateDomcontentloaded,
+			Timeout: Timeout(ctx, ms),
+		})
+	}
+
+	if ms := min(float64(b.settle.NetworkIdleMs), left()); ms > 0 {
+		_ = page.WaitForLoadState(playwright.PageWaitForLoadStateOptions{
+			State:   playwright.LoadStateNetworkidle,
+			Timeout: Timeout(ctx, ms),
+		})
+	}
+
+	if ms := left(); ms > 0 && b.PendingDialog() == nil {
+		// The page may be navigating; a failed install just skips this phase.
+		if _, err := page.Evaluate(quietScript); err == nil {
+			_, _ = page.WaitForFunction(waitQuietScript, b.settle.QuietMs, playwright.PageWaitForFunctionOptions{
+				Polling: playwright.Float(100),
+				Timeout: Timeout(ctx, ms),
+			})
+		}
+	}
+
+	return ctx.Err()
+}
+
+// MaxWait caps the duration of a wait action.
+func (b *Browser) MaxWait() time.Duration {
+	return time.Duration(b.settle.MaxWaitSec) * time.Second
+}
@@ -0,0 +1,161 @@
+package browser
+
+import (
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Tab describes an open page for the model.
+type Tab struct {
+	Index  int
+	URL    string
+	Title  string
+	Active bool
+}
+
+// track registers a page opened by the context (initial page, popup,
+// target=_blank link or open_tab) and makes it the active one.
+func (b *Browser) track(page playwright.Page) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, p := range b.pages {
+		if p == page {
+			return
+		}
+	}
+
+	b.pages = append(b.pages, page)
+	b.active = page
+
+	page.OnClose(func(p playwright.Page) {
+		b.untrack(p)
+	})
+	page.OnDownload(b.onDownload)
+	page.OnDialog(b.onDialog)
+}
+
+func (b *Browser) untrack(page playwright.Page) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, p := range b.pages {
+		if p == page {
+			b.pages = append(b.pages[:i], b.pages[i+1:]...)
+			break
+		}
+	}
+
+	if b.dialog != nil && b.dialog.Page() == page {
+		b.dialog = nil
+	}
+
+	if b.active == page && len(b.pages) > 0 {
+		b.active = b.pages[len(b.pages)-1]
+	}
+}
+
+// Active returns the page the agent currently works in.
+func (b *Browser) Active() playwright.Page {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.active
+}
+
+// Tabs lists open pages in the order they were opened.
+func (b *Browser) Tabs() []Tab {
+	b.mu.Lock()
+	pages := append([]playwright.Page(nil), b.pages...)
+	active := b.active
+	b.mu.Unlock()
+
+	tabs := make([]Tab, 0, len(pages))
+	for i, p := range pages {
+		title, _ := p.Title()
+		tabs = append(tabs, Tab{Index: i, URL: p.URL(), Title: title, Active: p == active})
+	}
+	return tabs
+}
+
+func (b *Browser) page(i int) (playwright.Page, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if i < 0 || i >= len(b.pages) {
+		return nil, fmt.Errorf("нет вкладки %d (открыто: %d)", i, len(b.pages))
+	}
+	return b.pages[i], nil
+}
+
+// SwitchTab makes tab i active and brings it to the front.
+func (b *Browser) SwitchTab(i int) error {
+	page, err := b.page(i)
+	if err != nil {
+		return err
+	}
+
+	if err = page.BringToFront(); err != nil {
+		return fmt.Errorf("не удалось переключиться на вкладку %d: %w", i, err)
+	}
+
+	b.mu.Lock()
+	b.active = page
+	b.mu.Unlock()
+	return nil
+}
+
+// CloseTab closes tab i. The last tab is never closed: the agent would have
+// nowhere to work.
+func (b *Browser) CloseTab(i int) error {
+	page, err := b.page(i)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	n := len(b.pages)
+	b.mu.Unlock()
+	if n == 1 {
+		return fmt.Errorf("нельзя закрыть последнюю вкладку")
+	}
+
+	if err = page.Close(); err != nil {
+		return fmt.Errorf("не удалось закрыть вкладку %d: %w", i, err)
+	}
+	b.untrack(page)
+
+	return b.Active().BringToFront()
+}
+
+// OpenTab opens url in a new active tab.
+func (b *Browser) OpenTab(url string, timeoutMs *float64) error {
+	page, err := b.Context.NewPage()
+	if err != nil {
+		return fmt.Errorf("new page: %w", err)
+	}
+	b.track(page)
+
+	if url == "" {
+		return nil
+	}
+
+	_, err = page.Goto(url, playwright.PageGotoOptions{
+		WaitUntil: playwright.WaitUntilStateDomcontentloaded,
+		Timeout:   timeoutMs,
+	})
+	return err
+}
+
+// ActiveIndex returns the position of the active tab.
+func (b *Browser) ActiveIndex() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, p := range b.pages {
+		if p == b.active {
+			return i
+		}
+	}
+	return -1
+}
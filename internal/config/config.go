@@ -24,6 +24,10 @@ type EnvConfig struct {
 	Env                string
 	ZaiAPIKey          string
 	ZaiBaseURL         string
+	AnthropicAPIKey    string
+	AnthropicBaseURL   string
+	OllamaBaseURL      string
+	LlamaCppBaseURL    string
 	BrowserUserDataDir string
 	BrowserHeadless    bool
 	BrowserSlowMoMs    int
@@ -39,6 +43,53 @@ type LLMConfig struct {
 	Model       string  `mapstructure:"model"`
 	MaxTokens   int     `mapstructure:"max_tokens"`
 	Temperature float32 `mapstructure:"temperature"`
+	// Mode selects how actions are requested: "json" (default) or "tools".
+	Mode string `mapstructure:"mode"`
+	// TimeoutMs bounds a single HTTP request to the provider.
+	TimeoutMs int `mapstructure:"timeout_ms"`
+	// ParseRetries is how many times the model is re-asked after an unparsable reply.
+	ParseRetries int         `mapstructure:"parse_retries"`
+	Retry        RetryConfig `mapstructure:"retry"`
+	// Fallbacks are tried in order when the primary model fails.
+	Fallbacks []ModelRef    `mapstructure:"fallbacks"`
+	Routing   RoutingConfig `mapstructure:"routing"`
+	// Pricing is a list rather than a map because model names contain dots.
+	Pricing  []ModelPrice   `mapstructure:"pricing"`
+	Cassette CassetteConfig `mapstructure:"cassette"`
+}
+
+// CassetteConfig records model replies to Path ("record") or serves them
+// from it without network access ("replay").
+type CassetteConfig struct {
+	Mode string `mapstructure:"mode"`
+	Path string `mapstructure:"path"`
+}
+
+// ModelPrice is the USD price per million tokens for one model.
+type ModelPrice struct {
+	Model           string  `mapstructure:"model"`
+	PromptPer1M     float64 `mapstructure:"prompt_per_1m"`
+	CompletionPer1M float64 `mapstructure:"completion_per_1m"`
+}
+
+type ModelRef struct {
+	Provider string `mapstructure:"provider"`
+	Model    string `mapstructure:"model"`
+}
+
+// RoutingConfig switches to Strong after EscalateAfterErrors consecutive
+// execution errors; the primary model handles all other steps.
+type RoutingConfig struct {
+	Strong              ModelRef `mapstructure:"strong"`
+	EscalateAfterErrors int      `mapstructure:"escalate_after_errors"`
+}
+
+type RetryConfig struct {
+	MaxAttempts int `mapstructure:"max_attempts"`
+	BaseDelayMs int `mapstructure:"base_delay_ms"`
+	// MaxDelayMs caps the backoff between attempts. A longer Retry-After
+	// from the server ends the call with a rate-limit error.
+	MaxDelayMs int `mapstructure:"max_delay_ms"`
 }
 
 type BrowserConfig struct {
@@ -48,15 +99,49 @@ type BrowserConfig struct {
 		Height int
 	}
 	TimeoutMs int
+	// Permissions are granted up front (e.g. geolocation, notifications);
+	// everything else is denied without showing a prompt.
+	Permissions []string     `mapstructure:"permissions"`
+	Settle      SettleConfig `mapstructure:"settle"`
+	// Snapshot selects how the page is read: "js" (DOM walker, default) or
+	// "ax" (accessibility tree, Chromium only).
+	Snapshot string `mapstructure:"snapshot"`
+}
+
+// SettleConfig bounds how long the executor waits for a page to settle
+// after an action; zero fields fall back to defaults.
+type SettleConfig struct {
+	NetworkIdleMs int `mapstructure:"network_idle_ms"`
+	// QuietMs is how long the DOM must go without mutations.
+	QuietMs int `mapstructure:"quiet_ms"`
+	// MaxMs caps the whole settle phase.
+	MaxMs int `mapstructure:"max_ms"`
+	// MaxWaitSec caps the model's wait action.
+	MaxWaitSec int `mapstructure:"max_wait_sec"`
 }
 
 type AgentConfig struct {
-	MaxSteps        int
-	AskConfirmation bool
+	MaxSteps        int  `mapstructure:"max_steps"`
+	AskConfirmation bool `mapstructure:"ask_confirmation"`
 	Memory          struct {
-		ShortTermSteps  int
-		MaxPageElements int
-	}
+		ShortTermSteps int `mapstructure:"short_term_steps"`
+		// MaxPageElements is how many of the snapshot's elements, ranked by
+		// relevance to the goal, the model sees per step; 0 means 80.
+		MaxPageElements int `mapstructure:"max_page_elements"`
+	} `mapstructure:"memory"`
+	Budget BudgetConfig `mapstructure:"budget"`
+	// TimeoutSec is the deadline for the whole run; zero means no deadline.
+	TimeoutSec int `mapstructure:"timeout_sec"`
+	// RunsDir holds one directory per run (downloads etc.).
+	RunsDir string `mapstructure:"runs_dir"`
+	// UploadsDir is the only directory upload_file may take files from.
+	UploadsDir string `mapstructure:"uploads_dir"`
+}
+
+// BudgetConfig stops a run once either limit is reached; zero disables it.
+type BudgetConfig struct {
+	MaxTokens  int     `mapstructure:"max_tokens"`
+	MaxCostUSD float64 `mapstructure:"max_cost_usd"`
 }
 
 type LoggingConfig struct {
@@ -81,12 +166,22 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("unmarshal config: %w", err)
 	}
 
-	cfg.Env = loadEnv()
+	// Fallback and strong models must be usable before the run needs them.
+	providers := []string{cfg.LLM.Provider}
+	for _, ref := range append([]ModelRef{cfg.LLM.Routing.Strong}, cfg.LLM.Fallbacks...) {
+		if ref.Provider != "" {
+			providers = append(providers, ref.Provider)
+		}
+	}
+	if cfg.LLM.Cassette.Mode == "replay" {
+		providers = []string{"dummy"} // replay never reaches a provider
+	}
+	cfg.Env = loadEnv(providers)
 
 	return cfg, nil
 }
 
-func loadEnv() EnvConfig {
+func loadEnv(providers []string) EnvConfig {
 	dir := getEnv("BROWSER_USER_DATA_DIR", "./data/browser")
 
 	absDir, err := filepath.Abs(dir)
@@ -96,14 +191,42 @@ func loadEnv() EnvConfig {
 
 	return EnvConfig{
 		Env:                getEnv("APP_ENV", "local"),
-		ZaiAPIKey:          mustEnv("ZAI_API_KEY"),
+		ZaiAPIKey:          providerEnv(providers, "ZAI_API_KEY"),
 		ZaiBaseURL:         getEnv("ZAI_BASE_URL", "https://api.z.ai/v1"),
+		AnthropicAPIKey:    providerEnv(providers, "ANTHROPIC_API_KEY"),
+		AnthropicBaseURL:   getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com/v1"),
+		OllamaBaseURL:      getEnv("OLLAMA_BASE_URL", "http://localhost:11434"),
+		LlamaCppBaseURL:    getEnv("LLAMACPP_BASE_URL", "http://localhost:8080/v1"),
 		BrowserUserDataDir: absDir,
 		BrowserHeadless:    getEnvBool("BROWSER_HEADLESS", false),
 		BrowserSlowMoMs:    getEnvInt("BROWSER_SLOW_MO_MS", 0),
 	}
 }
 
+// requiredKeys maps a provider to the API key env var it cannot run without.
+// Local providers (ollama, llamacpp) and dummy need none.
+var requiredKeys = map[string]string{
+	"":          "ZAI_API_KEY",
+	"zai":       "ZAI_API_KEY",
+	"openai":    "ZAI_API_KEY",
+	"mistral":   "ZAI_API_KEY",
+	"groq":      "ZAI_API_KEY",
+	"together":  "ZAI_API_KEY",
+	"anthropic": "ANTHROPIC_API_KEY",
+	"claude":    "ANTHROPIC_API_KEY",
+}
+
+// providerEnv reads key, panicking only if one of the configured providers
+// (primary, fallbacks, strong) requires it.
+func providerEnv(providers []string, key string) string {
+	for _, p := range providers {
+		if requiredKeys[strings.ToLower(p)] == key {
+			return mustEnv(key)
+		}
+	}
+	return os.Getenv(key)
+}
+
 func mustEnv(key string) string {
 	val := os.Getenv(key)
 	if val == "" {
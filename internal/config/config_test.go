@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProviderEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []string
+		key       string
+		env       string
+		want      string
+		wantPanic bool
+	}{
+		{"zai by default", []string{""}, "ZAI_API_KEY", "", "", true},
+		{"zai set", []string{"zai"}, "ZAI_API_KEY", "z-key", "z-key", false},
+		{"openai-compatible", []string{"groq"}, "ZAI_API_KEY", "", "", true},
+		{"anthropic", []string{"anthropic"}, "ANTHROPIC_API_KEY", "", "", true},
+		{"claude alias", []string{"Claude"}, "ANTHROPIC_API_KEY", "a-key", "a-key", false},
+		{"ollama needs no key", []string{"ollama"}, "ZAI_API_KEY", "", "", false},
+		{"llamacpp needs no key", []string{"llamacpp"}, "ANTHROPIC_API_KEY", "", "", false},
+		{"dummy needs no key", []string{"dummy"}, "ZAI_API_KEY", "", "", false},
+		{"optional key still read", []string{"ollama"}, "ANTHROPIC_API_KEY", "a-key", "a-key", false},
+		{"fallback requires key", []string{"ollama", "anthropic"}, "ANTHROPIC_API_KEY", "", "", true},
+		{"strong requires key", []string{"ollama", "zai"}, "ZAI_API_KEY", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.env)
+
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("panic = %v, want panic %v", r, tt.wantPanic)
+				}
+			}()
+			if got := providerEnv(tt.providers, tt.key); got != tt.want {
+				t.Errorf("providerEnv(%v, %s) = %q, want %q", tt.providers, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadChecksKeysOfAllProviders(t *testing.T) {
+	t.Setenv("ZAI_API_KEY", "")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	write := func(yaml string) string {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cfg, err := Load(write("llm:\n  provider: ollama\n  model: qwen\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LLM.Provider != "ollama" || cfg.Env.OllamaBaseURL == "" {
+		t.Errorf("config = %+v", cfg.LLM)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("a fallback without its API key was accepted")
+		}
+	}()
+	Load(write("llm:\n  provider: ollama\n  fallbacks:\n    - provider: anthropic\n      model: claude\n"))
+}
+
+func TestLoadReplayNeedsNoKeys(t *testing.T) {
+	t.Setenv("ZAI_API_KEY", "")
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	yaml := "llm:\n  provider: zai\n  cassette:\n    mode: replay\n    path: run.json\n"
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,10 @@
 package core
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
 
 type ActionType string
 
@@ -10,15 +14,111 @@ const (
 	ActionNavigate ActionType = "navigate"
 	ActionDone     ActionType = "done"
 	ActionPressKey ActionType = "press_key"
+	ActionScroll   ActionType = "scroll"
+	ActionSelect   ActionType = "select_option"
+	ActionCheck    ActionType = "check"
+	ActionUncheck  ActionType = "uncheck"
+	ActionHover    ActionType = "hover"
+	ActionDrag     ActionType = "drag"
+	ActionBack     ActionType = "go_back"
+	ActionForward  ActionType = "go_forward"
+	ActionReload   ActionType = "reload"
+	ActionSwitch   ActionType = "switch_tab"
+	ActionCloseTab ActionType = "close_tab"
+	ActionOpenTab  ActionType = "open_tab"
+	ActionExtract  ActionType = "extract"
+	ActionUpload   ActionType = "upload_file"
+	ActionDialog   ActionType = "dialog"
+	ActionWait     ActionType = "wait"
+	ActionBatch    ActionType = "batch"
+	ActionElements ActionType = "elements"
+)
+
+// Scroll directions.
+const (
+	ScrollDown   = "down"
+	ScrollUp     = "up"
+	ScrollTop    = "top"
+	ScrollBottom = "bottom"
 )
 
+// NoTarget marks an action that does not refer to a snapshot element.
+// Decoding JSON without a "target" field yields NoTarget, not index 0.
+const NoTarget = -1
+
 type Action struct {
 	Type   ActionType `json:"type"`
 	Target int        `json:"target,omitempty"`
 	Text   string     `json:"text,omitempty"`
 	URL    string     `json:"url,omitempty"`
-	Reason string     `json:"reason"`
+	Reason string     `json:"reason,omitempty"`
 	Key    string     `json:"key,omitempty"`
+	// Direction and Amount (pixels, 0 = one screen) describe a scroll.
+	Direction string `json:"direction,omitempty"`
+	Amount    int    `json:"amount,omitempty"`
+	// Option is the value or visible label to pick in a <select>.
+	Option string `json:"option,omitempty"`
+	// To is the element index drag drops the target onto.
+	To *int `json:"to,omitempty"`
+	// Tab is the tab index for switch_tab and close_tab; nil means the active tab.
+	Tab *int `json:"tab,omitempty"`
+	// Files are names relative to the configured uploads directory.
+	Files []string `json:"files,omitempty"`
+	// Accept answers a pending dialog; false dismisses it. Text is sent to prompts.
+	Accept bool `json:"accept,omitempty"`
+	// Role makes wait look for an element of this ARIA role, named Text
+	// if that is set, which need not be in the snapshot yet.
+	Role string `json:"role,omitempty"`
+	// Seconds is how long wait pauses when it has neither text nor target.
+	Seconds float64 `json:"seconds,omitempty"`
+	// Query says what extract is looking for; it is echoed in the history.
+	// For elements it filters the snapshot by text.
+	Query string `json:"query,omitempty"`
+	// Page is the 1-based page of the ranked snapshot elements shows.
+	Page int `json:"page,omitempty"`
+	// Actions is the ordered list run by a batch.
+	Actions []Action `json:"actions,omitempty"`
+	// Answer and Data are the final result carried by done. Data must match
+	// the run's result schema when one is configured.
+	Answer string          `json:"answer,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// HasTarget reports whether the action refers to a snapshot element.
+func (a Action) HasTarget() bool {
+	return a.Target >= 0
+}
+
+type actionAlias Action
+
+// actionJSON shadows Target with a pointer so index 0 survives encoding and
+// a missing target decodes to NoTarget.
+type actionJSON struct {
+	actionAlias
+	Target *int `json:"target,omitempty"`
+}
+
+func (a Action) MarshalJSON() ([]byte, error) {
+	aux := actionJSON{actionAlias: actionAlias(a)}
+	if a.HasTarget() {
+		t := a.Target
+		aux.Target = &t
+	}
+	return json.Marshal(aux)
+}
+
+func (a *Action) UnmarshalJSON(data []byte) error {
+	var aux actionJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*a = Action(aux.actionAlias)
+	a.Target = NoTarget
+	if aux.Target != nil {
+		a.Target = *aux.Target
+	}
+	return nil
 }
 
 func (a Action) String() string {
@@ -33,9 +133,95 @@ func (a Action) String() string {
 			textSnippet = textSnippet[:27] + "..."
 		}
 		return fmt.Sprintf("🛠️ Ввожу \"%s\" в поле %d", textSnippet, a.Target)
+	case ActionPressKey:
+		if a.HasTarget() {
+			return fmt.Sprintf("⌨️ Нажимаю %s на элементе %d", a.Key, a.Target)
+		}
+		return fmt.Sprintf("⌨️ Нажимаю клавишу %s", a.Key)
+	case ActionScroll:
+		where := "страницу"
+		if a.HasTarget() {
+			where = fmt.Sprintf("элемент %d", a.Target)
+		}
+		if a.Amount > 0 {
+			return fmt.Sprintf("📜 Прокручиваю %s: %s на %dpx", where, a.Direction, a.Amount)
+		}
+		return fmt.Sprintf("📜 Прокручиваю %s: %s", where, a.Direction)
+	case ActionSelect:
+		return fmt.Sprintf("🛠️ Выбираю %q в списке %d", a.Option, a.Target)
+	case ActionCheck:
+		return fmt.Sprintf("☑️ Отмечаю %d", a.Target)
+	case ActionUncheck:
+		return fmt.Sprintf("⬜ Снимаю отметку с %d", a.Target)
+	case ActionHover:
+		return fmt.Sprintf("🖱️ Навожу курсор на %d", a.Target)
+	case ActionDrag:
+		if a.To == nil {
+			return fmt.Sprintf("🖱️ Перетаскиваю %d", a.Target)
+		}
+		return fmt.Sprintf("🖱️ Перетаскиваю %d на %d", a.Target, *a.To)
+	case ActionBack:
+		return "⬅️ Возвращаюсь назад"
+	case ActionForward:
+		return "➡️ Перехожу вперёд"
+	case ActionReload:
+		return "🔄 Обновляю страницу"
+	case ActionSwitch:
+		return fmt.Sprintf("🗂️ Переключаюсь на вкладку %s", tabLabel(a.Tab))
+	case ActionCloseTab:
+		return fmt.Sprintf("🗂️ Закрываю вкладку %s", tabLabel(a.Tab))
+	case ActionOpenTab:
+		return fmt.Sprintf("🗂️ Открываю новую вкладку %s", a.URL)
+	case ActionExtract:
+		if a.HasTarget() {
+			return fmt.Sprintf("🔎 Извлекаю данные из элемента %d: %s", a.Target, a.Query)
+		}
+		return fmt.Sprintf("🔎 Извлекаю данные со страницы: %s", a.Query)
+	case ActionUpload:
+		return fmt.Sprintf("📎 Прикрепляю %s к элементу %d", strings.Join(a.Files, ", "), a.Target)
+	case ActionDialog:
+		if a.Accept {
+			return "💬 Подтверждаю диалог"
+		}
+		return "💬 Закрываю диалог"
+	case ActionWait:
+		switch {
+		case a.Role != "" && a.Text != "":
+			return fmt.Sprintf("⏳ Жду элемент %s %q", a.Role, a.Text)
+		case a.Role != "":
+			return fmt.Sprintf("⏳ Жду элемент %s", a.Role)
+		case a.Text != "":
+			return fmt.Sprintf("⏳ Жду появления текста %q", a.Text)
+		case a.HasTarget():
+			return fmt.Sprintf("⏳ Жду элемент %d", a.Target)
+		default:
+			return fmt.Sprintf("⏳ Жду %g с", a.Seconds)
+		}
+	case ActionBatch:
+		items := make([]string, 0, len(a.Actions))
+		for _, item := range a.Actions {
+			items = append(items, item.String())
+		}
+		return fmt.Sprintf("📦 Пакет из %d действий: %s", len(a.Actions), strings.Join(items, "; "))
+	case ActionElements:
+		page := a.Page
+		if page < 1 {
+			page = 1
+		}
+		if a.Query != "" {
+			return fmt.Sprintf("📋 Ищу элементы %q, страница %d", a.Query, page)
+		}
+		return fmt.Sprintf("📋 Показываю элементы, страница %d", page)
 	case ActionDone:
 		return "Задача выполнена! 🎉"
 	default:
 		return fmt.Sprintf("%s (неизвестный тип)", a.Type)
 	}
 }
+
+func tabLabel(tab *int) string {
+	if tab == nil {
+		return "(текущую)"
+	}
+	return fmt.Sprintf("%d", *tab)
+}
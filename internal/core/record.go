@@ -0,0 +1,68 @@
+package core
+
+import "time"
+
+// Observation is what the agent learned from executing one action.
+type Observation struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Text    string `json:"text,omitempty"`
+	// Warning is set when the action succeeded but its effect could not be
+	// confirmed, e.g. a click that changed nothing.
+	Warning string `json:"warning,omitempty"`
+	// Extracted holds the text returned by an extract action.
+	Extracted string `json:"extracted,omitempty"`
+	// Downloads lists files the site produced since the previous step.
+	Downloads []Download `json:"downloads,omitempty"`
+	// Batch reports how far a batch action got.
+	Batch *BatchResult `json:"batch,omitempty"`
+	// Dialog is set when the page opened a dialog that is still pending.
+	Dialog   *Dialog       `json:"dialog,omitempty"`
+	Diff     ElementDiff   `json:"diff"`
+	Duration time.Duration `json:"duration"`
+}
+
+// BatchResult tells which actions of a batch ran: the first Ran of Total.
+type BatchResult struct {
+	Ran   int `json:"ran"`
+	Total int `json:"total"`
+	// Stopped explains why the remaining actions were skipped.
+	Stopped string `json:"stopped,omitempty"`
+}
+
+// Dialog is a JavaScript alert, confirm, prompt or beforeunload dialog
+// waiting for the agent.
+type Dialog struct {
+	Type         string `json:"type"`
+	Message      string `json:"message"`
+	DefaultValue string `json:"default_value,omitempty"`
+}
+
+// Download is a file saved into the run's downloads folder.
+type Download struct {
+	Name  string `json:"name"`
+	Path  string `json:"path,omitempty"`
+	Size  int64  `json:"size"`
+	MIME  string `json:"mime,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ElementDiff compares interactive elements before the action and on the
+// next snapshot, by role and name.
+type ElementDiff struct {
+	Before  int      `json:"before"`
+	After   int      `json:"after"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// StepRecord is one entry of the run history.
+type StepRecord struct {
+	Step        int         `json:"step"`
+	Action      Action      `json:"action"`
+	Observation Observation `json:"observation"`
+	Provider    string      `json:"provider,omitempty"`
+	Model       string      `json:"model,omitempty"`
+}
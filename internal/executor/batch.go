@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const maxBatchActions = 8
+
+// BatchError is returned when a batch did not run to the end. Err is nil
+// when the batch was stopped on purpose (the page navigated, the snapshot
+// no longer matches or an action had no visible effect), which is not a
+// failure.
+type BatchError struct {
+	Ran, Total int
+	Reason     string
+	Err        error
+}
+
+func (e *BatchError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("пакет остановлен после %d из %d действий: %s", e.Ran, e.Total, e.Reason)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// batch runs a.Actions in order. Indices in every item refer to view, the
+// snapshot the model chose the batch from, and are resolved by element id
+// (see resolve). Before each item after the first the batch stops if the
+// page navigated or one of the item's elements is gone.
+func (e *PlaywrightExecutor) batch(ctx context.Context, a *core.Action, view []interpreter.Element) error {
+	total := len(a.Actions)
+	if total == 0 {
+		return fmt.Errorf("batch требует непустое поле 'actions'")
+	}
+	if total > maxBatchActions {
+		return fmt.Errorf("batch: слишком много действий (%d), максимум %d", total, maxBatchActions)
+	}
+	for i, item := range a.Actions {
+		switch item.Type {
+		case core.ActionBatch, core.ActionExtract, core.ActionElements, core.ActionDone:
+			return fmt.Errorf("batch: действие %d (%s) нельзя выполнять внутри пакета", i+1, item.Type)
+		}
+	}
+
+	var err error
+	if view == nil {
+		if view, err = e.i.Snapshot(ctx); err != nil {
+			return err
+		}
+	}
+	page, url := e.page, e.page.URL()
+
+	for i := range a.Actions {
+		item := &a.Actions[i]
+
+		if i > 0 {
+			if reason := e.batchStop(item, page, url); reason != "" {
+				return &BatchError{Ran: i, Total: total, Reason: reason}
+			}
+		}
+
+		err = e.Execute(ctx, item, view)
+
+		// The rest of the batch assumed this action worked.
+		var unverified *UnverifiedError
+		if errors.As(err, &unverified) {
+			return &BatchError{Ran: i + 1, Total: total, Reason: unverified.Msg}
+		}
+		if i > 0 && errors.Is(err, ErrElementChanged) {
+			return &BatchError{Ran: i, Total: total, Reason: err.Error()}
+		}
+		if err != nil {
+			return &BatchError{
+				Ran:   i,
+				Total: total,
+				Err:   fmt.Errorf("действие %d из %d (%s): %w", i+1, total, item.Type, err),
+			}
+		}
+	}
+
+	return nil
+}
+
+// batchStop returns why the batch must not continue with item, or "".
+func (e *PlaywrightExecutor) batchStop(item *core.Action, page playwright.Page, url string) string {
+	if d := e.br.PendingDialog(); d != nil && item.Type != core.ActionDialog {
+		return fmt.Sprintf("открыт диалог %s", d.Type)
+	}
+	if e.page != page {
+		return "сменилась активная вкладка"
+	}
+	if now := e.page.URL(); now != url {
+		return fmt.Sprintf("страница перешла на %s", now)
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"ai-browser-agent/internal/core"
+)
+
+var destructiveKeywords = []string{
+	"оплатить", "купить", "заказать", "подтвердить", "удалить", "удаление",
+	"delete", "remove", "pay", "checkout", "оформить", "buy", "submit order",
+}
+
+// destructiveKeyword returns the first destructive keyword found in any of
+// texts, or "" if there is none.
+func destructiveKeyword(texts ...string) string {
+	for _, t := range texts {
+		lower := strings.ToLower(t)
+		for _, kw := range destructiveKeywords {
+			if strings.Contains(lower, kw) {
+				return kw
+			}
+		}
+	}
+	return ""
+}
+
+// confirm asks the user in the terminal before a potentially destructive
+// action and returns an error unless they agree. Cancelling ctx stops the
+// wait for an answer.
+func confirm(ctx context.Context, a *core.Action, reason string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n⚠️ ВНИМАНИЕ: потенциально деструктивное действие!\n")
+	fmt.Printf("Действие: %s\n", a.String())
+	fmt.Printf("Причина: %s\n", reason)
+	fmt.Print("Подтвердить выполнение? (y/n): ")
+
+	answer := make(chan string, 1)
+	go func() {
+		var input string
+		fmt.Scanln(&input)
+		answer <- input
+	}()
+
+	var input string
+	select {
+	case input = <-answer:
+	case <-ctx.Done():
+		fmt.Println()
+		return ctx.Err()
+	}
+	input = strings.ToLower(strings.TrimSpace(input))
+
+	if input != "y" && input != "yes" {
+		return fmt.Errorf("действие отменено пользователем")
+	}
+
+	log.Println("Действие подтверждено пользователем")
+	return nil
+}
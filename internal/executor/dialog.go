@@ -0,0 +1,28 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"ai-browser-agent/internal/core"
+)
+
+// dialog answers the pending JavaScript dialog. Accepting a dialog whose
+// message looks destructive goes through the same confirmation as clicks.
+func (e *PlaywrightExecutor) dialog(ctx context.Context, a *core.Action) error {
+	d := e.br.PendingDialog()
+	if d == nil {
+		return fmt.Errorf("нет открытого диалога")
+	}
+
+	if a.Accept {
+		if kw := destructiveKeyword(d.Message); kw != "" {
+			reason := fmt.Sprintf("Диалог %s содержит подозрительное слово: %q (%q)", d.Type, kw, d.Message)
+			if err := confirm(ctx, a, reason); err != nil {
+				return err
+			}
+		}
+	}
+
+	return e.br.HandleDialog(a.Accept, a.Text)
+}
@@ -0,0 +1,45 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ai-browser-agent/internal/core"
+)
+
+func TestClickOpeningDialog(t *testing.T) {
+	e := testExecutor(t, `<button onclick="alert(1)">Открыть</button>`)
+
+	ctx := context.Background()
+	els, err := e.i.Snapshot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(els) != 1 {
+		t.Fatalf("elements: %d, want 1", len(els))
+	}
+
+	start := time.Now()
+	if err = e.Execute(ctx, &core.Action{Type: core.ActionClick, Target: 0}, els); err != nil {
+		t.Fatalf("click: %v", err)
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Errorf("click took %v: it waited for the dialog to close", took)
+	}
+
+	d := e.br.PendingDialog()
+	if d == nil {
+		t.Fatal("no pending dialog")
+	}
+	if d.Type != "alert" || d.Message != "1" {
+		t.Errorf("dialog = %+v", d)
+	}
+
+	if err = e.Execute(ctx, &core.Action{Type: core.ActionDialog, Accept: true}, nil); err != nil {
+		t.Fatalf("dialog: %v", err)
+	}
+	if d := e.br.PendingDialog(); d != nil {
+		t.Errorf("dialog still pending: %+v", d)
+	}
+}
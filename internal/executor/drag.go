@@ -0,0 +1,36 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// drag drags the target element onto the element given by a.To.
+func (e *PlaywrightExecutor) drag(ctx context.Context, a *core.Action, els []interpreter.Element) error {
+	if a.To == nil {
+		return fmt.Errorf("drag требует поле 'to' — индекс элемента, куда перетащить")
+	}
+	if *a.To == a.Target {
+		return fmt.Errorf("drag: target и to совпадают (%d)", a.Target)
+	}
+
+	src, _, err := e.target(ctx, a, els)
+	if err != nil {
+		return err
+	}
+	dst, _, err := e.resolve(ctx, *a.To, els)
+	if err != nil {
+		return err
+	}
+
+	if err = src.DragTo(dst, playwright.LocatorDragToOptions{Timeout: browser.Timeout(ctx, 10000)}); err != nil {
+		return fmt.Errorf("не удалось перетащить элемент %d на %d: %w", a.Target, *a.To, err)
+	}
+	return nil
+}
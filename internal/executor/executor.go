@@ -1,9 +1,14 @@
 package executor
 
 import (
+	"context"
+
 	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
 )
 
 type Executor interface {
-	Execute(action *core.Action) error
+	// Execute performs action. Target indices refer to view, the snapshot
+	// the model chose the action from; with a nil view a fresh one is taken.
+	Execute(ctx context.Context, action *core.Action, view []interpreter.Element) error
 }
@@ -0,0 +1,39 @@
+package executor
+
+import (
+	"testing"
+
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/config"
+	"ai-browser-agent/internal/interpreter"
+)
+
+// testExecutor opens html in a headless browser. The test is skipped where
+// Playwright or its browsers are not installed.
+func testExecutor(t *testing.T, html string) *PlaywrightExecutor {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("браузерный тест")
+	}
+
+	cfg := &config.Config{}
+	cfg.Env.BrowserUserDataDir = t.TempDir()
+	cfg.Env.BrowserHeadless = true
+	cfg.Browser.Viewport.Width = 1280
+	cfg.Browser.Viewport.Height = 800
+	cfg.Browser.TimeoutMs = 30000
+	cfg.Agent.RunsDir = t.TempDir()
+	cfg.Agent.UploadsDir = t.TempDir()
+
+	br, err := browser.Launch(cfg)
+	if err != nil {
+		t.Skipf("браузер недоступен: %v", err)
+	}
+	t.Cleanup(br.Close)
+
+	page := br.Active()
+	if err = page.SetContent(html); err != nil {
+		t.Fatal(err)
+	}
+	return New(cfg, br, interpreter.New(page, interpreter.SnapshotJS))
+}
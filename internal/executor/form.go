@@ -0,0 +1,148 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// selectOption picks an option by value, falling back to its visible label,
+// and checks that the select really changed.
+func (e *PlaywrightExecutor) selectOption(ctx context.Context, a *core.Action, els []interpreter.Element) error {
+	if a.Option == "" {
+		return fmt.Errorf("select_option требует поле 'option'")
+	}
+
+	loc, el, err := e.target(ctx, a, els)
+	if err != nil {
+		return err
+	}
+
+	if len(el.Options) == 0 && el.Role != "select" && el.Role != "combobox" {
+		return fmt.Errorf("элемент %d (%s) не является выпадающим списком <select>", a.Target, el.Role)
+	}
+
+	// Playwright waits for a matching option until timeout, so decide
+	// whether the model gave a value or a label first: from the snapshot, or
+	// from the page when the snapshot did not list every option in full.
+	var byValue, found bool
+	if el.OptionsTruncated {
+		byValue, found, err = findOption(loc, a.Option)
+		if err != nil {
+			return fmt.Errorf("не удалось прочитать опции списка %d: %w", a.Target, err)
+		}
+	} else {
+		byValue, found = hasOption(el.Options, a.Option)
+		found = found || len(el.Options) == 0
+	}
+	if !found {
+		return fmt.Errorf("в списке %d нет опции %q (доступно: %s)", a.Target, a.Option, optionLabels(el))
+	}
+
+	values := playwright.SelectOptionValues{Labels: &[]string{a.Option}}
+	if byValue {
+		values = playwright.SelectOptionValues{Values: &[]string{a.Option}}
+	}
+
+	selected, err := loc.SelectOption(values, playwright.LocatorSelectOptionOptions{Timeout: browser.Timeout(ctx, 10000)})
+	if err != nil {
+		return fmt.Errorf("не удалось выбрать %q в списке %d: %w", a.Option, a.Target, err)
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("в списке %d нет опции %q (доступно: %s)", a.Target, a.Option, optionLabels(el))
+	}
+
+	return nil
+}
+
+// setChecked toggles a checkbox or radio and verifies the resulting state.
+func (e *PlaywrightExecutor) setChecked(ctx context.Context, a *core.Action, els []interpreter.Element, want bool) error {
+	loc, _, err := e.target(ctx, a, els)
+	if err != nil {
+		return err
+	}
+
+	// Custom-styled checkboxes often hide the real input, hence Force.
+	if err = loc.SetChecked(want, playwright.LocatorSetCheckedOptions{
+		Timeout: browser.Timeout(ctx, 10000),
+		Force:   playwright.Bool(true),
+	}); err != nil {
+		return fmt.Errorf("не удалось изменить отметку элемента %d: %w", a.Target, err)
+	}
+
+	got, err := loc.IsChecked()
+	if err != nil {
+		return fmt.Errorf("не удалось проверить состояние элемента %d: %w", a.Target, err)
+	}
+	if got != want {
+		return fmt.Errorf("элемент %d остался в состоянии checked=%v", a.Target, got)
+	}
+
+	return nil
+}
+
+func (e *PlaywrightExecutor) hover(ctx context.Context, a *core.Action, els []interpreter.Element) error {
+	loc, _, err := e.target(ctx, a, els)
+	if err != nil {
+		return err
+	}
+
+	if err = loc.Hover(playwright.LocatorHoverOptions{Timeout: browser.Timeout(ctx, 10000)}); err != nil {
+		return fmt.Errorf("не удалось навести курсор на элемент %d: %w", a.Target, err)
+	}
+
+	return nil
+}
+
+// hasOption looks option up among opts, by value first.
+func hasOption(opts []interpreter.Option, option string) (byValue, found bool) {
+	for _, o := range opts {
+		if o.Value == option {
+			return true, true
+		}
+	}
+	for _, o := range opts {
+		if o.Label == option {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// findOption is hasOption against the live <select>, for option lists the
+// snapshot shortened.
+func findOption(loc playwright.Locator, option string) (byValue, found bool, err error) {
+	raw, err := loc.Evaluate(`(el, v) => {
+        const opts = Array.from(el.options || []);
+        if (opts.some(o => o.value === v)) return "value";
+        if (opts.some(o => o.label === v)) return "label";
+        return "";
+    }`, option)
+	if err != nil {
+		return false, false, err
+	}
+	switch raw {
+	case "value":
+		return true, true, nil
+	case "label":
+		return false, true, nil
+	}
+	return false, false, nil
+}
+
+func optionLabels(el interpreter.Element) string {
+	labels := make([]string, 0, len(el.Options))
+	for _, o := range el.Options {
+		labels = append(labels, fmt.Sprintf("%q", o.Label))
+	}
+	if el.OptionsTruncated {
+		labels = append(labels, "…")
+	}
+	return strings.Join(labels, ", ")
+}
@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// modifierKeys may precede the main key of a chord such as "Control+A".
+var modifierKeys = map[string]bool{
+	"Shift": true, "Control": true, "Alt": true, "Meta": true, "ControlOrMeta": true,
+	"ShiftLeft": true, "ShiftRight": true, "ControlLeft": true, "ControlRight": true,
+	"AltLeft": true, "AltRight": true, "MetaLeft": true, "MetaRight": true,
+}
+
+// namedKeys are the non-character key names Playwright's keyboard accepts.
+var namedKeys = func() map[string]bool {
+	keys := map[string]bool{}
+	for _, k := range []string{
+		"Backquote", "Minus", "Equal", "Backslash", "Backspace", "Tab", "Delete", "Escape",
+		"ArrowDown", "ArrowUp", "ArrowLeft", "ArrowRight", "End", "Enter", "Home", "Insert",
+		"PageDown", "PageUp", "Space", "BracketLeft", "BracketRight", "Semicolon", "Quote",
+		"Comma", "Period", "Slash", "CapsLock", "ContextMenu", "NumLock", "ScrollLock",
+		"Pause", "PrintScreen", "IntlBackslash", "AltGraph",
+		"NumpadDivide", "NumpadMultiply", "NumpadSubtract", "NumpadAdd", "NumpadDecimal",
+		"NumpadEnter", "NumpadEqual",
+	} {
+		keys[k] = true
+	}
+	for i := 1; i <= 12; i++ {
+		keys[fmt.Sprintf("F%d", i)] = true
+	}
+	for c := '0'; c <= '9'; c++ {
+		keys["Digit"+string(c)] = true
+		keys["Numpad"+string(c)] = true
+	}
+	for c := 'A'; c <= 'Z'; c++ {
+		keys["Key"+string(c)] = true
+	}
+	for k := range modifierKeys {
+		keys[k] = true
+	}
+	return keys
+}()
+
+// validateKey checks a key or chord ("Enter", "a", "Control+Shift+ArrowLeft")
+// against Playwright's key names, so a typo reaches the model as a clear
+// error instead of a failed keyboard call.
+func validateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("press_key требует поле 'key'")
+	}
+
+	// Split off the main key at the last "+" that is not the key itself,
+	// so "Control++" presses plus with Control.
+	main, mods := key, []string(nil)
+	if i := strings.LastIndex(key[:len(key)-1], "+"); i >= 0 {
+		main, mods = key[i+1:], strings.Split(key[:i], "+")
+	}
+
+	for _, m := range mods {
+		if !modifierKeys[m] {
+			if hint := suggestKey(m); modifierKeys[hint] {
+				return fmt.Errorf("некорректное сочетание клавиш %q: %q не модификатор, возможно %q", key, m, hint)
+			}
+			return fmt.Errorf("некорректное сочетание клавиш %q: %q не модификатор (Shift, Control, Alt, Meta, ControlOrMeta)", key, m)
+		}
+	}
+
+	if namedKeys[main] || utf8.RuneCountInString(main) == 1 {
+		return nil
+	}
+	if hint := suggestKey(main); hint != "" {
+		return fmt.Errorf("неизвестная клавиша %q, возможно %q", main, hint)
+	}
+	return fmt.Errorf("неизвестная клавиша %q: используй имена Playwright (Enter, Escape, Tab, ArrowDown, PageDown, Backspace, F5, KeyA) или один символ", main)
+}
+
+// suggestKey finds a key name that differs from p only in case or by a
+// common alias.
+func suggestKey(p string) string {
+	aliases := map[string]string{
+		"esc": "Escape", "return": "Enter", "del": "Delete", "ctrl": "Control",
+		"cmd": "Meta", "command": "Meta", "option": "Alt", "up": "ArrowUp",
+		"down": "ArrowDown", "left": "ArrowLeft", "right": "ArrowRight",
+		"pgup": "PageUp", "pgdn": "PageDown", "spacebar": "Space",
+	}
+	lower := strings.ToLower(p)
+	if k, ok := aliases[lower]; ok {
+		return k
+	}
+	for k := range namedKeys {
+		if strings.ToLower(k) == lower {
+			return k
+		}
+	}
+	return ""
+}
+
+// pressKey presses a key or chord on the target element (focusing it
+// first) or, without a target, wherever the focus currently is.
+func (e *PlaywrightExecutor) pressKey(ctx context.Context, a *core.Action, els []interpreter.Element) error {
+	if err := validateKey(a.Key); err != nil {
+		return err
+	}
+
+	if !a.HasTarget() {
+		return e.page.Keyboard().Press(a.Key)
+	}
+
+	loc, _, err := e.target(ctx, a, els)
+	if err != nil {
+		return err
+	}
+	if err = loc.Press(a.Key, playwright.LocatorPressOptions{Timeout: browser.Timeout(ctx, 10000)}); err != nil {
+		return fmt.Errorf("не удалось нажать %s на элементе %d: %w", a.Key, a.Target, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string // substring of the error; "" means valid
+	}{
+		{"Enter", ""},
+		{"a", ""},
+		{"Я", ""},
+		{"Control+A", ""},
+		{"Control+Shift+ArrowLeft", ""},
+		{"ControlOrMeta+KeyC", ""},
+		{"Control++", ""},
+		{"+", ""},
+		{"ctrl+a", `возможно "Control"`},
+		{"Control+Esc", `возможно "Escape"`},
+		{"Esc", `возможно "Escape"`},
+		{"enter", `возможно "Enter"`},
+		{"pgdn", `возможно "PageDown"`},
+		{"Control+", "неизвестная клавиша"},
+		{"Foo+A", "не модификатор"},
+		{"Hello", "неизвестная клавиша"},
+		{"", "требует поле 'key'"},
+	}
+	for _, tt := range tests {
+		err := validateKey(tt.key)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("validateKey(%q) = %v, want nil", tt.key, err)
+		case tt.want != "" && err == nil:
+			t.Errorf("validateKey(%q) = nil, want an error with %q", tt.key, tt.want)
+		case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+			t.Errorf("validateKey(%q) = %v, want an error with %q", tt.key, err, tt.want)
+		}
+	}
+}
+
+func TestSuggestKey(t *testing.T) {
+	tests := map[string]string{
+		"esc":       "Escape",
+		"ESC":       "Escape",
+		"return":    "Enter",
+		"ctrl":      "Control",
+		"arrowdown": "ArrowDown",
+		"f5":        "F5",
+		"keya":      "KeyA",
+		"Hello":     "",
+	}
+	for in, want := range tests {
+		if got := suggestKey(in); got != want {
+			t.Errorf("suggestKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package executor
 
 import (
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/config"
 	"ai-browser-agent/internal/core"
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"strings"
-	"time"
 
 	"ai-browser-agent/internal/interpreter"
 
@@ -13,125 +15,163 @@ import (
 )
 
 type PlaywrightExecutor struct {
-	page playwright.Page
-	i    *interpreter.Interpreter
+	br         *browser.Browser
+	page       playwright.Page
+	i          *interpreter.Interpreter
+	uploadsDir string
 }
 
-func New(page playwright.Page, i *interpreter.Interpreter) *PlaywrightExecutor {
-	return &PlaywrightExecutor{page: page, i: i}
+func New(cfg *config.Config, br *browser.Browser, i *interpreter.Interpreter) *PlaywrightExecutor {
+	e := &PlaywrightExecutor{br: br, i: i, uploadsDir: cfg.Agent.UploadsDir}
+	e.sync()
+	return e
 }
 
-func (e *PlaywrightExecutor) Execute(a *core.Action) error {
-	els, err := e.i.Snapshot()
-	if err != nil {
-		return err
+// sync rebinds the executor and interpreter to the browser's active tab,
+// which changes on switch_tab/close_tab and when a popup opens.
+func (e *PlaywrightExecutor) sync() {
+	if page := e.br.Active(); page != e.page {
+		e.page = page
+		e.i.SetPage(page)
 	}
+}
+
+// Execute performs the action and waits for the page to settle.
+func (e *PlaywrightExecutor) Execute(ctx context.Context, a *core.Action, view []interpreter.Element) error {
+	e.sync()
 
-	destructiveKeywords := []string{
-		"оплатить", "купить", "заказать", "подтвердить", "удалить", "удаление",
-		"delete", "remove", "pay", "checkout", "оформить", "buy", "submit order",
+	// The page's scripts are blocked until the dialog is answered, so
+	// nothing else can run against it.
+	if a.Type == core.ActionDialog {
+		return e.dialog(ctx, a)
+	}
+	if d := e.br.PendingDialog(); d != nil && a.Type != core.ActionDone {
+		return fmt.Errorf("на странице открыт диалог %s (%q): сначала ответь на него действием dialog", d.Type, d.Message)
 	}
 
-	isDestructive := false
-	destructiveReason := ""
+	if a.Type == core.ActionBatch {
+		return e.batch(ctx, a, view)
+	}
 
-	if a.Type == core.ActionClick || a.Type == core.ActionTypeText || a.Type == core.ActionPressKey {
-		if a.Target >= 0 && a.Target < len(els) {
-			el := els[a.Target]
-			nameLower := strings.ToLower(el.Name)
-			roleLower := strings.ToLower(el.Role)
-			selectorLower := strings.ToLower(el.Selector)
-
-			for _, kw := range destructiveKeywords {
-				if strings.Contains(nameLower, kw) ||
-					strings.Contains(roleLower, kw) ||
-					strings.Contains(selectorLower, kw) {
-					isDestructive = true
-					destructiveReason = fmt.Sprintf("Элемент содержит подозрительное слово: %q (name=%q, role=%q)", kw, el.Name, el.Role)
-					break
-				}
-			}
+	before := e.page.URL()
+
+	var m *pageMark
+	if a.Type == core.ActionClick {
+		m = e.mark()
+	}
+
+	opened, err := e.br.UntilDialog(ctx, func() error {
+		if isTabAction(a.Type) {
+			return e.tabAction(ctx, a)
 		}
+		return e.execute(ctx, a, view)
+	})
+	if isTabAction(a.Type) {
+		e.sync()
+	}
+
+	// The action opened a dialog: it did its job, and the page cannot
+	// settle until the model answers the dialog.
+	if opened || (err == nil && e.br.PendingDialog() != nil) {
+		return nil
+	}
+
+	// A warning from execute still lets the page settle before it is reported.
+	var unverified *UnverifiedError
+	if errors.As(err, &unverified) {
+		err = nil
+	}
+	if err != nil {
+		return err
 	}
 
-	if isDestructive {
-		fmt.Printf("\n⚠️ ВНИМАНИЕ: потенциально деструктивное действие!\n")
-		fmt.Printf("Действие: %s\n", a.String())
-		fmt.Printf("Причина: %s\n", destructiveReason)
-		fmt.Print("Подтвердить выполнение? (y/n): ")
+	if a.Type == core.ActionDone || a.Type == core.ActionWait {
+		return nil
+	}
 
-		var input string
-		fmt.Scanln(&input)
-		input = strings.ToLower(strings.TrimSpace(input))
+	// A click may have opened a new tab; follow it.
+	e.sync()
+	if err = e.br.Settle(ctx, e.page, before); err != nil {
+		return err
+	}
+
+	if m != nil && !e.changed(m) {
+		return &UnverifiedError{Msg: fmt.Sprintf("действие не дало видимого эффекта: клик по элементу %d не изменил ни URL, ни страницу, ни фокус", a.Target)}
+	}
+	if unverified != nil {
+		return unverified
+	}
+	return nil
+}
 
-		if input != "y" && input != "yes" {
-			return fmt.Errorf("действие отменено пользователем")
+func (e *PlaywrightExecutor) execute(ctx context.Context, a *core.Action, els []interpreter.Element) error {
+	var err error
+	if els == nil {
+		if els, err = e.i.Snapshot(ctx); err != nil {
+			return err
 		}
+	}
 
-		log.Println("Действие подтверждено пользователем")
+	if a.Type == core.ActionClick || a.Type == core.ActionTypeText || a.Type == core.ActionPressKey ||
+		a.Type == core.ActionSelect || a.Type == core.ActionCheck || a.Type == core.ActionDrag {
+		if a.Target >= 0 && a.Target < len(els) {
+			el := els[a.Target]
+			if kw := destructiveKeyword(el.Name, el.Role, el.Selector); kw != "" {
+				reason := fmt.Sprintf("Элемент содержит подозрительное слово: %q (name=%q, role=%q)", kw, el.Name, el.Role)
+				if err = confirm(ctx, a, reason); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
 	switch a.Type {
 	case core.ActionClick:
-		if a.Target < 0 || a.Target >= len(els) {
-			return fmt.Errorf("invalid target index: %d (elements: %d)", a.Target, len(els))
+		loc, el, err := e.resolve(ctx, a.Target, els)
+		if err != nil {
+			return err
 		}
-
-		el := els[a.Target]
-		sel := el.Selector
-
 		log.Printf("Попытка клика по элементу %d: selector=%s, name=%q, role=%s, inViewport=%v",
-			a.Target, sel, el.Name, el.Role, el.InViewport)
-
-		loc := e.page.Locator(sel).First()
+			a.Target, el.Ref(), el.Name, el.Role, el.InViewport)
 
 		if err = loc.ScrollIntoViewIfNeeded(); err != nil {
 			log.Printf("Предупреждение: не удалось проскроллить к элементу: %v", err)
 		}
 
-		time.Sleep(300 * time.Millisecond)
-
 		if err = loc.WaitFor(playwright.LocatorWaitForOptions{
 			State:   playwright.WaitForSelectorStateVisible,
-			Timeout: playwright.Float(10000),
+			Timeout: browser.Timeout(ctx, 10000),
 		}); err != nil {
-			return fmt.Errorf("элемент %d (%s) не стал видимым за 10с: %w", a.Target, sel, err)
+			return fmt.Errorf("элемент %d (%q) не стал видимым за 10с: %w", a.Target, el.Name, err)
 		}
 
 		if err = loc.Click(playwright.LocatorClickOptions{
-			Timeout: playwright.Float(10000),
+			Timeout: browser.Timeout(ctx, 10000),
 			Force:   playwright.Bool(false),
 		}); err != nil {
 			log.Printf("Обычный клик не сработал, пробуем force click: %v", err)
 			if err = loc.Click(playwright.LocatorClickOptions{
-				Timeout: playwright.Float(10000),
+				Timeout: browser.Timeout(ctx, 10000),
 				Force:   playwright.Bool(true),
 			}); err != nil {
 				return fmt.Errorf("не удалось кликнуть даже с force: %w", err)
 			}
 		}
-
-		time.Sleep(500 * time.Millisecond)
 		return nil
 
 	case core.ActionTypeText:
-		if a.Target < 0 || a.Target >= len(els) {
-			return fmt.Errorf("invalid target index: %d", a.Target)
+		loc, _, err := e.resolve(ctx, a.Target, els)
+		if err != nil {
+			return err
 		}
 
-		el := els[a.Target]
-		sel := el.Selector
-		loc := e.page.Locator(sel).First()
-
 		if err = loc.ScrollIntoViewIfNeeded(); err != nil {
 			log.Printf("Предупреждение: не удалось проскроллить к полю ввода: %v", err)
 		}
 
-		time.Sleep(300 * time.Millisecond)
-
 		if err = loc.WaitFor(playwright.LocatorWaitForOptions{
 			State:   playwright.WaitForSelectorStateVisible,
-			Timeout: playwright.Float(10000),
+			Timeout: browser.Timeout(ctx, 10000),
 		}); err != nil {
 			return fmt.Errorf("поле ввода %d не стало видимым: %w", a.Target, err)
 		}
@@ -148,24 +188,41 @@ func (e *PlaywrightExecutor) Execute(a *core.Action) error {
 			return fmt.Errorf("не удалось ввести текст: %w", err)
 		}
 
-		time.Sleep(300 * time.Millisecond)
-		return nil
+		return verifyInput(ctx, loc, a)
 
 	case core.ActionNavigate:
-		_, err = e.page.Goto(a.URL, playwright.PageGotoOptions{
+		resp, err := e.page.Goto(a.URL, playwright.PageGotoOptions{
 			WaitUntil: playwright.WaitUntilStateDomcontentloaded,
-			Timeout:   playwright.Float(15000),
+			Timeout:   browser.Timeout(ctx, 15000),
 		})
 		if err != nil {
 			return err
 		}
-		time.Sleep(1500 * time.Millisecond)
+		return verifyNavigation(a.URL, e.page.URL(), resp)
 
 	case core.ActionPressKey:
-		if a.Key == "" {
-			return fmt.Errorf("press_key требует поле 'key'")
-		}
-		return e.page.Keyboard().Press(a.Key)
+		return e.pressKey(ctx, a, els)
+
+	case core.ActionDrag:
+		return e.drag(ctx, a, els)
+
+	case core.ActionScroll:
+		return e.scroll(ctx, a, els)
+
+	case core.ActionSelect:
+		return e.selectOption(ctx, a, els)
+
+	case core.ActionCheck, core.ActionUncheck:
+		return e.setChecked(ctx, a, els, a.Type == core.ActionCheck)
+
+	case core.ActionHover:
+		return e.hover(ctx, a, els)
+
+	case core.ActionUpload:
+		return e.upload(ctx, a, els)
+
+	case core.ActionWait:
+		return e.wait(ctx, a, els)
 
 	case core.ActionDone:
 		return nil
@@ -173,6 +230,4 @@ func (e *PlaywrightExecutor) Execute(a *core.Action) error {
 	default:
 		return fmt.Errorf("неизвестный тип действия: %s", a.Type)
 	}
-
-	return nil
 }
@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// ErrElementChanged means the element the model chose is no longer on the
+// page in the form it was shown.
+var ErrElementChanged = errors.New("element changed")
+
+// resolve finds the DOM node behind index idx of the snapshot the model was
+// shown, inside the element's iframe if it has one: first by its
+// data-agent-id, then, if the page re-rendered the node, by the element's
+// candidate locators. A candidate is only used when it matches exactly one
+// element that still has the snapshotted role and name; otherwise resolve
+// fails with ErrElementChanged rather than act on whatever now sits at that
+// index.
+func (e *PlaywrightExecutor) resolve(ctx context.Context, idx int, els []interpreter.Element) (playwright.Locator, interpreter.Element, error) {
+	if idx < 0 || idx >= len(els) {
+		return nil, interpreter.Element{}, fmt.Errorf("invalid target index: %d (elements: %d)", idx, len(els))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, interpreter.Element{}, err
+	}
+
+	el := els[idx]
+	candidates := el.Locators
+	if el.ID != "" {
+		candidates = append([]interpreter.Locator{{Kind: interpreter.LocatorCSS, Value: el.Ref()}}, candidates...)
+	} else if len(candidates) == 0 {
+		candidates = []interpreter.Locator{{Kind: interpreter.LocatorCSS, Value: el.Selector}}
+	}
+
+	scope := interpreter.ScopeOf(e.page, el)
+	for i, c := range candidates {
+		// With an id, a tag path only says what sits at the element's old
+		// position now.
+		if el.ID != "" && i > 0 && c.Kind == interpreter.LocatorCSS && positional(c.Value) {
+			continue
+		}
+
+		loc := scope.Locator(c)
+		if n, err := loc.Count(); err != nil || n != 1 {
+			continue
+		}
+		if el.ID == "" || i > 0 {
+			same, err := interpreter.SameElement(loc, el)
+			if err != nil || !same {
+				log.Printf("Запасной локатор %s %q элемента %d указывает на другой элемент", c.Kind, c.Value, idx)
+				break
+			}
+			log.Printf("Элемент %d найден по запасному локатору %s %q", idx, c.Kind, c.Value)
+		}
+		return loc, el, nil
+	}
+
+	return nil, el, fmt.Errorf("%w: элемент %d (%s %q) исчез или изменился после snapshot — выбери элемент из нового snapshot", ErrElementChanged, idx, el.Role, el.Name)
+}
+
+// target resolves a.Target and scrolls it into view.
+func (e *PlaywrightExecutor) target(ctx context.Context, a *core.Action, els []interpreter.Element) (playwright.Locator, interpreter.Element, error) {
+	loc, el, err := e.resolve(ctx, a.Target, els)
+	if err != nil {
+		return nil, el, err
+	}
+
+	if err = loc.ScrollIntoViewIfNeeded(); err != nil {
+		log.Printf("Предупреждение: не удалось проскроллить к элементу %d: %v", a.Target, err)
+	}
+
+	return loc, el, nil
+}
+
+// locate is target without scrolling: the element may not be visible yet.
+func (e *PlaywrightExecutor) locate(ctx context.Context, a *core.Action, els []interpreter.Element) (playwright.Locator, error) {
+	loc, _, err := e.resolve(ctx, a.Target, els)
+	return loc, err
+}
+
+// positional reports whether a CSS path picks the node by its place in the
+// tree rather than by an id or attribute.
+func positional(sel string) bool {
+	return strings.Contains(sel, ":nth-of-type(") || !strings.ContainsAny(sel, "#[")
+}
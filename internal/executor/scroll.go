@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// scrollScript scrolls the nearest scrollable ancestor of el, el itself
+// included (or the page when el is null or has none), and reports whether
+// the position actually changed, so the model learns it hit the end. The
+// container itself is often not in the snapshot, only its items are.
+const scrollScript = `
+([el, direction, amount]) => {
+    const scrollable = node => {
+        const overflow = getComputedStyle(node).overflowY;
+        return (overflow === "auto" || overflow === "scroll" || overflow === "overlay") &&
+            node.scrollHeight > node.clientHeight;
+    };
+
+    let box = el;
+    while (box && !scrollable(box)) box = box.parentElement;
+    const inner = !!box;
+    if (!inner) box = document.scrollingElement || document.documentElement;
+
+    const before = box.scrollTop;
+    const step = amount > 0 ? amount : Math.round((inner ? box.clientHeight : window.innerHeight) * 0.9);
+
+    switch (direction) {
+        case "up":     box.scrollBy({ top: -step }); break;
+        case "down":   box.scrollBy({ top: step }); break;
+        case "top":    box.scrollTo({ top: 0 }); break;
+        case "bottom": box.scrollTo({ top: box.scrollHeight }); break;
+    }
+
+    return { moved: box.scrollTop !== before, top: box.scrollTop, height: box.scrollHeight };
+}`
+
+func (e *PlaywrightExecutor) scroll(ctx context.Context, a *core.Action, els []interpreter.Element) error {
+	switch a.Direction {
+	case core.ScrollDown, core.ScrollUp, core.ScrollTop, core.ScrollBottom:
+	case "":
+		a.Direction = core.ScrollDown
+	default:
+		return fmt.Errorf("scroll: неизвестное направление %q (up, down, top, bottom)", a.Direction)
+	}
+
+	var (
+		result interface{}
+		err    error
+	)
+
+	if a.HasTarget() {
+		var el playwright.Locator
+		if el, _, err = e.resolve(ctx, a.Target, els); err != nil {
+			return err
+		}
+		result, err = el.Evaluate(`(el, args) => (`+scrollScript+`)([el, args[0], args[1]])`, []interface{}{a.Direction, a.Amount})
+	} else {
+		result, err = e.page.Evaluate(scrollScript, []interface{}{nil, a.Direction, a.Amount})
+	}
+	if err != nil {
+		return fmt.Errorf("не удалось прокрутить: %w", err)
+	}
+
+	if m, ok := result.(map[string]interface{}); ok {
+		if moved, _ := m["moved"].(bool); !moved {
+			return fmt.Errorf("прокрутка %s не сдвинула содержимое: достигнут край или элемент не прокручивается", a.Direction)
+		}
+	}
+
+	return nil
+}
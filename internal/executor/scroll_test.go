@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"ai-browser-agent/internal/core"
+)
+
+func TestScrollContainerOfItem(t *testing.T) {
+	var items strings.Builder
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&items, `<button style="display:block;height:40px">Пункт %d</button>`, i)
+	}
+	e := testExecutor(t, `<div id="list" style="height:200px;overflow-y:auto">`+items.String()+`</div>`)
+
+	ctx := context.Background()
+	els, err := e.i.Snapshot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The container is not in the snapshot; its first item stands for it.
+	if err = e.scroll(ctx, &core.Action{Type: core.ActionScroll, Direction: core.ScrollDown, Target: 0}, els); err != nil {
+		t.Fatalf("scroll: %v", err)
+	}
+
+	moved, err := e.page.Evaluate(`() => document.getElementById("list").scrollTop > 0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != true {
+		t.Error("the list did not scroll")
+	}
+}
@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/core"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+func isTabAction(t core.ActionType) bool {
+	switch t {
+	case core.ActionBack, core.ActionForward, core.ActionReload,
+		core.ActionSwitch, core.ActionCloseTab, core.ActionOpenTab:
+		return true
+	}
+	return false
+}
+
+// tabAction handles history and tab actions, which need no snapshot.
+func (e *PlaywrightExecutor) tabAction(ctx context.Context, a *core.Action) error {
+	switch a.Type {
+	case core.ActionBack:
+		resp, err := e.page.GoBack(playwright.PageGoBackOptions{
+			WaitUntil: playwright.WaitUntilStateDomcontentloaded,
+			Timeout:   browser.Timeout(ctx, 15000),
+		})
+		if err != nil {
+			return fmt.Errorf("не удалось вернуться назад: %w", err)
+		}
+		if resp == nil {
+			return fmt.Errorf("в истории вкладки нет предыдущей страницы")
+		}
+
+	case core.ActionForward:
+		resp, err := e.page.GoForward(playwright.PageGoForwardOptions{
+			WaitUntil: playwright.WaitUntilStateDomcontentloaded,
+			Timeout:   browser.Timeout(ctx, 15000),
+		})
+		if err != nil {
+			return fmt.Errorf("не удалось перейти вперёд: %w", err)
+		}
+		if resp == nil {
+			return fmt.Errorf("в истории вкладки нет следующей страницы")
+		}
+
+	case core.ActionReload:
+		if _, err := e.page.Reload(playwright.PageReloadOptions{
+			WaitUntil: playwright.WaitUntilStateDomcontentloaded,
+			Timeout:   browser.Timeout(ctx, 15000),
+		}); err != nil {
+			return fmt.Errorf("не удалось обновить страницу: %w", err)
+		}
+
+	case core.ActionSwitch:
+		if a.Tab == nil {
+			return fmt.Errorf("switch_tab требует поле 'tab'")
+		}
+		return e.br.SwitchTab(*a.Tab)
+
+	case core.ActionCloseTab:
+		tab := e.br.ActiveIndex()
+		if a.Tab != nil {
+			tab = *a.Tab
+		}
+		return e.br.CloseTab(tab)
+
+	case core.ActionOpenTab:
+		return e.br.OpenTab(a.URL, browser.Timeout(ctx, 15000))
+	}
+
+	return nil
+}
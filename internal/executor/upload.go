@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// upload attaches files from the uploads directory to a file input, or to
+// the file chooser opened by clicking the target (styled upload buttons).
+func (e *PlaywrightExecutor) upload(ctx context.Context, a *core.Action, els []interpreter.Element) error {
+	if len(a.Files) == 0 {
+		return fmt.Errorf("upload_file требует поле 'files'")
+	}
+
+	paths, err := e.uploadPaths(a.Files)
+	if err != nil {
+		return err
+	}
+
+	loc, el, err := e.target(ctx, a, els)
+	if err != nil {
+		return err
+	}
+
+	if el.Role == "input" {
+		if err = loc.SetInputFiles(paths, playwright.LocatorSetInputFilesOptions{
+			Timeout: browser.Timeout(ctx, 10000),
+		}); err == nil {
+			return nil
+		}
+	}
+
+	chooser, err := e.page.ExpectFileChooser(func() error {
+		return loc.Click(playwright.LocatorClickOptions{Timeout: browser.Timeout(ctx, 10000)})
+	}, playwright.PageExpectFileChooserOptions{Timeout: browser.Timeout(ctx, 10000)})
+	if err != nil {
+		return fmt.Errorf("элемент %d не открыл выбор файла: %w", a.Target, err)
+	}
+
+	if err = chooser.SetFiles(paths); err != nil {
+		return fmt.Errorf("не удалось прикрепить файлы: %w", err)
+	}
+	return nil
+}
+
+// uploadPaths resolves names inside the allowlisted uploads directory and
+// rejects anything that escapes it, symlinks included, or does not exist.
+func (e *PlaywrightExecutor) uploadPaths(names []string) ([]string, error) {
+	if e.uploadsDir == "" {
+		return nil, fmt.Errorf("загрузка файлов отключена: не задан agent.uploads_dir")
+	}
+
+	dir, err := filepath.Abs(e.uploadsDir)
+	if err != nil {
+		return nil, err
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return nil, fmt.Errorf("папка загрузок недоступна: %w", err)
+	}
+
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.Clean("/"+name))
+
+		// A link inside the folder may lead anywhere: check its target.
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return nil, fmt.Errorf("файл %q не найден в папке загрузок (%s)", name, listDir(dir))
+		}
+		if !within(dir, path) || !within(dir, real) {
+			return nil, fmt.Errorf("файл %q вне разрешённой папки загрузок", name)
+		}
+
+		st, err := os.Stat(real)
+		if err != nil || st.IsDir() {
+			return nil, fmt.Errorf("файл %q не найден в папке загрузок (%s)", name, listDir(dir))
+		}
+
+		paths = append(paths, real)
+	}
+
+	return paths, nil
+}
+
+// within reports whether path is dir itself or lies inside it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func listDir(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) == 0 {
+		return "папка пуста"
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return "доступно: " + strings.Join(names, ", ")
+}
@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadPaths(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	for _, f := range []string{filepath.Join(dir, "cv.pdf"), filepath.Join(dir, "docs", "a.txt"), filepath.Join(outside, "secret.txt")} {
+		if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{
+		"escape.txt": filepath.Join(outside, "secret.txt"),
+		"escdir":     outside,
+		"alias.pdf":  filepath.Join(dir, "cv.pdf"),
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		want string // path relative to dir; "" expects an error
+	}{
+		{"cv.pdf", "cv.pdf"},
+		{"docs/a.txt", "docs/a.txt"},
+		{"./docs/../cv.pdf", "cv.pdf"},
+		{"../cv.pdf", "cv.pdf"},
+		{"/cv.pdf", "cv.pdf"},
+		{"alias.pdf", "cv.pdf"},
+		{"../" + filepath.Base(outside) + "/secret.txt", ""},
+		{filepath.Join(outside, "secret.txt"), ""},
+		{`..\secret.txt`, ""},
+		{"escape.txt", ""},
+		{"escdir/secret.txt", ""},
+		{"docs", ""},
+		{"missing.pdf", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &PlaywrightExecutor{uploadsDir: dir}
+
+			paths, err := e.uploadPaths([]string{tt.name})
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("uploadPaths(%q) = %v, want an error", tt.name, paths)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("uploadPaths(%q): %v", tt.name, err)
+			}
+			if want := filepath.Join(realPath(t, dir), tt.want); paths[0] != want {
+				t.Errorf("uploadPaths(%q) = %s, want %s", tt.name, paths[0], want)
+			}
+		})
+	}
+}
+
+func TestUploadPathsDisabled(t *testing.T) {
+	e := &PlaywrightExecutor{}
+	if _, err := e.uploadPaths([]string{"cv.pdf"}); err == nil {
+		t.Error("uploads without agent.uploads_dir must be rejected")
+	}
+}
+
+func realPath(t *testing.T, path string) string {
+	t.Helper()
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return real
+}
@@ -0,0 +1,144 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/core"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// UnverifiedError means the action ran without errors but its expected
+// effect could not be observed. It is reported to the model as a warning
+// rather than a failure.
+type UnverifiedError struct {
+	Msg string
+}
+
+func (e *UnverifiedError) Error() string {
+	return e.Msg
+}
+
+// markScript counts DOM mutations (ignoring the agent's own data-agent-*
+// attributes), input/change/toggle events and focus moves since it was
+// first installed, so two calls tell whether anything happened between them.
+// Scroll position is left out: the executor scrolls the target into view
+// before clicking, which must not count as the click's effect.
+const markScript = `() => {
+    if (!window.__agentMarks) {
+        const m = window.__agentMarks = { mutations: 0, events: 0, focus: 0 };
+        new MutationObserver((records) => {
+            for (const r of records) {
+                if (r.type === "attributes" && r.attributeName.startsWith("data-agent")) continue;
+                m.mutations++;
+            }
+        }).observe(document, { subtree: true, childList: true, attributes: true, characterData: true });
+        for (const ev of ["input", "change", "toggle"]) {
+            document.addEventListener(ev, () => m.events++, true);
+        }
+        document.addEventListener("focusin", () => m.focus++, true);
+    }
+    const m = window.__agentMarks;
+    return [m.mutations, m.events, m.focus];
+}`
+
+// pageMark is what a click could change: the page and its URL, tabs,
+// dialogs, downloads and the counters of markScript.
+type pageMark struct {
+	page      playwright.Page
+	url       string
+	tabs      int
+	downloads int
+	dom       []interface{}
+}
+
+// mark records the page state before an action; nil if it cannot be read.
+func (e *PlaywrightExecutor) mark() *pageMark {
+	dom, err := e.page.Evaluate(markScript)
+	if err != nil {
+		return nil
+	}
+	counters, _ := dom.([]interface{})
+
+	return &pageMark{
+		page:      e.page,
+		url:       e.page.URL(),
+		tabs:      len(e.br.Tabs()),
+		downloads: e.br.DownloadsStarted(),
+		dom:       counters,
+	}
+}
+
+// changed reports whether anything observable happened since m.
+func (e *PlaywrightExecutor) changed(m *pageMark) bool {
+	if e.page != m.page || e.page.URL() != m.url || len(e.br.Tabs()) != m.tabs ||
+		e.br.DownloadsStarted() != m.downloads || e.br.PendingDialog() != nil {
+		return true
+	}
+
+	dom, err := e.page.Evaluate(markScript)
+	if err != nil {
+		// The document is gone or busy, which is a change in itself.
+		return true
+	}
+	counters, _ := dom.([]interface{})
+	if len(counters) != len(m.dom) {
+		return true
+	}
+	for i := range counters {
+		if counters[i] != m.dom[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// sameSite reports whether got is on the host that was asked for, treating
+// a leading "www." as insignificant.
+func sameSite(want, got string) bool {
+	w, err1 := url.Parse(want)
+	g, err2 := url.Parse(got)
+	if err1 != nil || err2 != nil || w.Host == "" {
+		return true
+	}
+	return strings.TrimPrefix(w.Hostname(), "www.") == strings.TrimPrefix(g.Hostname(), "www.")
+}
+
+// verifyInput reads the field back after fill. An empty field is a failure;
+// a different value (input masks, length limits) is only a warning.
+func verifyInput(ctx context.Context, loc playwright.Locator, a *core.Action) error {
+	got, err := loc.InputValue(playwright.LocatorInputValueOptions{Timeout: browser.Timeout(ctx, 2000)})
+	if err != nil {
+		// contenteditable and other non-form fields have no value.
+		if got, err = loc.InnerText(playwright.LocatorInnerTextOptions{Timeout: browser.Timeout(ctx, 2000)}); err != nil {
+			return nil
+		}
+		if strings.Contains(got, a.Text) {
+			return nil
+		}
+	}
+
+	switch {
+	case got == a.Text:
+		return nil
+	case got == "" && a.Text != "":
+		return fmt.Errorf("текст не появился в поле %d: после ввода поле пустое", a.Target)
+	default:
+		return &UnverifiedError{Msg: fmt.Sprintf("поле %d содержит %q вместо %q (сайт мог изменить ввод)", a.Target, got, a.Text)}
+	}
+}
+
+// verifyNavigation checks the HTTP status and where the browser ended up.
+func verifyNavigation(want, got string, resp playwright.Response) error {
+	if resp != nil && resp.Status() >= 400 {
+		return &UnverifiedError{Msg: fmt.Sprintf("страница %s ответила HTTP %d", got, resp.Status())}
+	}
+	if !sameSite(want, got) {
+		return &UnverifiedError{Msg: fmt.Sprintf("переход на %s перенаправлен на %s", want, got)}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai-browser-agent/internal/browser"
+	"ai-browser-agent/internal/core"
+	"ai-browser-agent/internal/interpreter"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// wait pauses until text appears on the page (inside the target, if given),
+// until an element with a.Role (named a.Text, if set) appears there, until
+// the target becomes visible, or for a.Seconds. Every variant is capped by
+// the browser's MaxWait.
+func (e *PlaywrightExecutor) wait(ctx context.Context, a *core.Action, els []interpreter.Element) error {
+	max := e.br.MaxWait()
+	limit := max
+	if a.Seconds > 0 {
+		limit = min(time.Duration(a.Seconds*float64(time.Second)), max)
+	}
+
+	switch {
+	case a.Role != "":
+		var name interface{}
+		if a.Text != "" {
+			name = a.Text
+		}
+
+		loc := e.page.GetByRole(playwright.AriaRole(a.Role), playwright.PageGetByRoleOptions{Name: name})
+		if a.HasTarget() {
+			scope, err := e.locate(ctx, a, els)
+			if err != nil {
+				return err
+			}
+			loc = scope.GetByRole(playwright.AriaRole(a.Role), playwright.LocatorGetByRoleOptions{Name: name})
+		}
+
+		if err := loc.First().WaitFor(playwright.LocatorWaitForOptions{
+			State:   playwright.WaitForSelectorStateVisible,
+			Timeout: browser.Timeout(ctx, float64(limit.Milliseconds())),
+		}); err != nil {
+			if a.Text != "" {
+				return fmt.Errorf("элемент %s %q не появился за %s", a.Role, a.Text, limit)
+			}
+			return fmt.Errorf("элемент %s не появился за %s", a.Role, limit)
+		}
+
+	case a.Text != "":
+		scope := e.page.GetByText(a.Text)
+		if a.HasTarget() {
+			loc, err := e.locate(ctx, a, els)
+			if err != nil {
+				return err
+			}
+			scope = loc.GetByText(a.Text)
+		}
+
+		if err := scope.First().WaitFor(playwright.LocatorWaitForOptions{
+			State:   playwright.WaitForSelectorStateVisible,
+			Timeout: browser.Timeout(ctx, float64(limit.Milliseconds())),
+		}); err != nil {
+			return fmt.Errorf("текст %q не появился за %s", a.Text, limit)
+		}
+
+	case a.HasTarget():
+		loc, err := e.locate(ctx, a, els)
+		if err != nil {
+			return err
+		}
+		if err = loc.WaitFor(playwright.LocatorWaitForOptions{
+			State:   playwright.WaitForSelectorStateVisible,
+			Timeout: browser.Timeout(ctx, float64(limit.Milliseconds())),
+		}); err != nil {
+			return fmt.Errorf("элемент %d не стал видимым за %s", a.Target, limit)
+		}
+
+	case a.Seconds > 0:
+		return browser.Sleep(ctx, limit)
+
+	default:
+		return fmt.Errorf("wait требует поле 'text', 'role', 'target' или 'seconds'")
+	}
+
+	return nil
+}
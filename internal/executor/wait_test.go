@@ -0,0 +1,27 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"ai-browser-agent/internal/core"
+)
+
+func TestWaitForRole(t *testing.T) {
+	e := testExecutor(t, `<p>Загрузка</p>
+<script>setTimeout(() => {
+    const b = document.createElement("button");
+    b.textContent = "Готово";
+    document.body.appendChild(b);
+}, 300)</script>`)
+
+	ctx := context.Background()
+	if err := e.wait(ctx, &core.Action{Type: core.ActionWait, Role: "button", Text: "Готово", Target: core.NoTarget, Seconds: 5}, nil); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+
+	err := e.wait(ctx, &core.Action{Type: core.ActionWait, Role: "link", Target: core.NoTarget, Seconds: 0.5}, nil)
+	if err == nil {
+		t.Error("wait for a missing link succeeded")
+	}
+}
@@ -0,0 +1,339 @@
+package interpreter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+type axValue struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type axNode struct {
+	NodeID     string   `json:"nodeId"`
+	Ignored    bool     `json:"ignored"`
+	Role       *axValue `json:"role"`
+	Name       *axValue `json:"name"`
+	Value      *axValue `json:"value"`
+	Properties []struct {
+		Name  string  `json:"name"`
+		Value axValue `json:"value"`
+	} `json:"properties"`
+	ParentID         string   `json:"parentId"`
+	ChildIDs         []string `json:"childIds"`
+	BackendDOMNodeID int      `json:"backendDOMNodeId"`
+}
+
+func (n *axNode) role() string {
+	if n.Role == nil {
+		return ""
+	}
+	s, _ := n.Role.Value.(string)
+	return s
+}
+
+func (n *axNode) name() string {
+	if n.Name == nil {
+		return ""
+	}
+	s, _ := n.Name.Value.(string)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func (n *axNode) value() string {
+	if n.Value == nil || n.Value.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(n.Value.Value)
+}
+
+// prop returns the value of an AX property ("checked", "expanded", ...).
+func (n *axNode) prop(name string) (interface{}, bool) {
+	for _, p := range n.Properties {
+		if p.Name == name {
+			return p.Value.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (n *axNode) flag(name string) bool {
+	v, _ := n.prop(name)
+	b, _ := v.(bool)
+	return b
+}
+
+// tristate reads a boolean-like property, which Chrome reports as a bool or
+// as "true"/"false"/"mixed".
+func (n *axNode) tristate(name string) *bool {
+	v, ok := n.prop(name)
+	if !ok {
+		return nil
+	}
+	var b bool
+	switch t := v.(type) {
+	case bool:
+		b = t
+	case string:
+		b = t == "true" || t == "mixed"
+	}
+	return &b
+}
+
+var axInteractiveRoles = map[string]bool{
+	"button": true, "link": true, "textbox": true, "searchbox": true, "combobox": true,
+	"listbox": true, "option": true, "checkbox": true, "radio": true, "switch": true,
+	"slider": true, "spinbutton": true, "menuitem": true, "menuitemcheckbox": true,
+	"menuitemradio": true, "tab": true, "treeitem": true,
+}
+
+// axGroupRoles are containers worth naming as an element's context.
+var axGroupRoles = map[string]bool{
+	"dialog": true, "alertdialog": true, "navigation": true, "form": true, "search": true,
+	"menu": true, "menubar": true, "tablist": true, "toolbar": true, "region": true,
+	"banner": true, "main": true, "complementary": true, "contentinfo": true,
+	"grid": true, "table": true, "tree": true, "radiogroup": true,
+}
+
+func axInteractive(n *axNode) bool {
+	if n.Ignored || n.BackendDOMNodeID == 0 {
+		return false
+	}
+	role := n.role()
+	if axInteractiveRoles[role] {
+		return true
+	}
+	switch role {
+	case "RootWebArea", "WebArea", "Iframe", "document", "StaticText", "InlineTextBox":
+		return false
+	}
+	// Custom widgets without a role are still reachable by keyboard.
+	return n.flag("focusable") && n.name() != ""
+}
+
+// axStampScript runs with this bound to the DOM node of an AX node. It tags
+// the node like snapshotScript does and reports its layout.
+const axStampScript = `function() {` + agentIDScript + `
+    const el = this.nodeType === Node.ELEMENT_NODE ? this : this.parentElement;
+    if (!el) return null;
+    const id = agentId(el);
+    const rect = el.getBoundingClientRect();
+    const style = window.getComputedStyle(el);
+    const visible = style.display !== "none" && style.visibility !== "hidden" &&
+                    style.opacity !== "0" && rect.width > 0 && rect.height > 0;
+    const inViewport = rect.top >= 0 && rect.left >= 0 &&
+                       rect.bottom <= window.innerHeight && rect.right <= window.innerWidth;
+    const height = window.innerHeight;
+    const distance = rect.bottom < 0 ? -rect.bottom : rect.top > height ? rect.top - height : 0;
+    return { id, visible, inViewport, distance: Math.round(distance) };
+}`
+
+type axStamp struct {
+	ID         string `json:"id"`
+	Visible    bool   `json:"visible"`
+	InViewport bool   `json:"inViewport"`
+	Distance   int    `json:"distance"`
+}
+
+// snapshotAX builds the snapshot from the accessibility tree of the main
+// frame (iframes are not read in this mode). Roles and names are the ones
+// the browser exposes to assistive technology, so custom widgets with ARIA
+// show up and decorative clickable divs do not.
+func (i *Interpreter) snapshotAX(ctx context.Context) ([]Element, error) {
+	session, err := i.page.Context().NewCDPSession(i.page)
+	if err != nil {
+		return nil, fmt.Errorf("CDP недоступен (режим ax работает только в Chromium): %w", err)
+	}
+	defer session.Detach()
+
+	send := func(method string, params map[string]interface{}, out interface{}) error {
+		res, err := session.Send(method, params)
+		if err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+		if out == nil {
+			return nil
+		}
+		raw, err := json.Marshal(res)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(raw, out)
+	}
+
+	if err = send("DOM.getDocument", map[string]interface{}{"depth": 0}, nil); err != nil {
+		return nil, err
+	}
+
+	var tree struct {
+		Nodes []*axNode `json:"nodes"`
+	}
+	if err = send("Accessibility.getFullAXTree", map[string]interface{}{}, &tree); err != nil {
+		return nil, err
+	}
+	if len(tree.Nodes) == 0 {
+		return nil, nil
+	}
+
+	byID := make(map[string]*axNode, len(tree.Nodes))
+	roleNames := map[string]int{}
+	for _, n := range tree.Nodes {
+		byID[n.NodeID] = n
+		if !n.Ignored {
+			roleNames[n.role()+"\x00"+n.name()]++
+		}
+	}
+
+	// Walk from the root in tree order, so indices follow the page like
+	// the JS walker's do.
+	var (
+		elements []Element
+		walk     func(n *axNode, depth int, group string, inSelect bool)
+	)
+	walk = func(n *axNode, depth int, group string, inSelect bool) {
+		if len(elements) >= maxElements || ctx.Err() != nil {
+			return
+		}
+		role := n.role()
+
+		// Options of a native <select> are listed on the select itself.
+		if axInteractive(n) && !(inSelect && role == "option") {
+			if el, ok := axElement(send, n, byID, roleNames); ok {
+				el.Index = len(elements)
+				el.Depth = depth
+				el.Group = group
+				elements = append(elements, el)
+			}
+		}
+
+		if !n.Ignored {
+			depth++
+			if axGroupRoles[role] {
+				group = role
+				if name := n.name(); name != "" {
+					group += fmt.Sprintf(" %q", truncate(name, 40))
+				}
+			}
+		}
+		inSelect = inSelect || role == "combobox" || role == "MenuListPopup"
+
+		for _, id := range n.ChildIDs {
+			if child := byID[id]; child != nil {
+				walk(child, depth, group, inSelect)
+			}
+		}
+	}
+	walk(tree.Nodes[0], 0, "", false)
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return elements, nil
+}
+
+// axElement turns an AX node into an Element, tagging its DOM node so the
+// executor can find it again.
+func axElement(send func(string, map[string]interface{}, interface{}) error, n *axNode, byID map[string]*axNode, roleNames map[string]int) (Element, bool) {
+	var resolved struct {
+		Object struct {
+			ObjectID string `json:"objectId"`
+		} `json:"object"`
+	}
+	if err := send("DOM.resolveNode", map[string]interface{}{"backendNodeId": n.BackendDOMNodeID}, &resolved); err != nil {
+		return Element{}, false
+	}
+	defer send("Runtime.releaseObject", map[string]interface{}{"objectId": resolved.Object.ObjectID}, nil)
+
+	var called struct {
+		Result struct {
+			Value *axStamp `json:"value"`
+		} `json:"result"`
+	}
+	if err := send("Runtime.callFunctionOn", map[string]interface{}{
+		"objectId":            resolved.Object.ObjectID,
+		"functionDeclaration": axStampScript,
+		"returnByValue":       true,
+	}, &called); err != nil {
+		log.Printf("Предупреждение: не удалось пометить узел %s: %v", n.NodeID, err)
+		return Element{}, false
+	}
+	stamp := called.Result.Value
+	if stamp == nil {
+		return Element{}, false
+	}
+
+	role, name := n.role(), n.name()
+	el := Element{
+		ID:         stamp.ID,
+		Role:       role,
+		Name:       name,
+		Value:      truncate(n.value(), 100),
+		Disabled:   n.flag("disabled"),
+		Visible:    stamp.Visible,
+		IsHidden:   !stamp.Visible,
+		InViewport: stamp.InViewport,
+		Distance:   stamp.Distance,
+		Required:   n.flag("required"),
+		Expanded:   n.tristate("expanded"),
+		Checked:    n.tristate("checked"),
+	}
+	if role == "option" || role == "tab" || role == "treeitem" {
+		el.Selected = n.tristate("selected")
+	}
+	if el.Name == "" {
+		el.Name = "(без имени)"
+	}
+
+	if name != "" && roleNames[role+"\x00"+name] == 1 {
+		el.Locators = append(el.Locators, Locator{Kind: LocatorRole, Role: role, Value: name})
+	}
+	el.Locators = append(el.Locators, Locator{Kind: LocatorCSS, Value: el.Ref()})
+
+	if role == "combobox" || role == "listbox" {
+		el.Options, el.OptionsTruncated = axOptions(n, byID)
+	}
+
+	return el, true
+}
+
+// axOptions collects the options below a select or listbox and reports
+// whether some were left out or cut short. Values are not exposed in the
+// AX tree, so options are matched by label.
+func axOptions(n *axNode, byID map[string]*axNode) ([]Option, bool) {
+	var opts []Option
+	truncated := false
+	var walk func(n *axNode)
+	walk = func(n *axNode) {
+		for _, id := range n.ChildIDs {
+			child := byID[id]
+			if child == nil {
+				continue
+			}
+			if child.role() == "option" || child.role() == "MenuListOption" {
+				name := child.name()
+				if len(opts) >= 30 || len([]rune(name)) > 60 {
+					truncated = true
+				}
+				if len(opts) < 30 {
+					sel := child.tristate("selected")
+					opts = append(opts, Option{Label: truncate(name, 60), Selected: sel != nil && *sel})
+				}
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(n)
+	return opts, truncated
+}
+
+func truncate(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max])
+	}
+	return s
+}
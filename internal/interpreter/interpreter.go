@@ -1,45 +1,182 @@
 package interpreter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 
+	"ai-browser-agent/internal/browser"
+
 	"github.com/playwright-community/playwright-go"
 )
 
+// Snapshot backends.
+const (
+	// SnapshotJS walks the DOM with a script and keeps elements that look
+	// interactive (tags, roles, cursor, handlers).
+	SnapshotJS = "js"
+	// SnapshotAX reads the browser accessibility tree over CDP.
+	SnapshotAX = "ax"
+)
+
 type Interpreter struct {
 	page playwright.Page
+	mode string
 }
 
-func New(page playwright.Page) *Interpreter {
+// New creates an interpreter using the given snapshot backend; "" means
+// SnapshotJS.
+func New(page playwright.Page, mode string) *Interpreter {
+	if mode == "" {
+		mode = SnapshotJS
+	}
 	return &Interpreter{
 		page: page,
+		mode: mode,
 	}
 }
 
-func (i *Interpreter) Snapshot() ([]Element, error) {
+// Mode returns the snapshot backend in use.
+func (i *Interpreter) Mode() string {
+	return i.mode
+}
+
+// SetPage rebinds the interpreter to another tab.
+func (i *Interpreter) SetPage(page playwright.Page) {
+	i.page = page
+}
+
+func (i *Interpreter) Snapshot(ctx context.Context) ([]Element, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	_, err := i.page.WaitForFunction(`
         () => document.body && document.body.children.length > 0
     `, playwright.PageWaitForFunctionOptions{
-		Timeout: playwright.Float(15000),
+		Timeout: browser.Timeout(ctx, 15000),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("страница не загрузилась (body не найден): %w", err)
 	}
 
-	_, err = i.page.WaitForFunction(`
-        () => document.readyState === 'complete' &&
-              document.body &&
-              document.body.children.length > 0
-    `, playwright.PageWaitForFunctionOptions{
-		Timeout: playwright.Float(15000),
-	})
+	// The executor lets the page settle after each action (browser.Settle).
+
+	switch i.mode {
+	case SnapshotJS:
+		return i.snapshotJS()
+	case SnapshotAX:
+		return i.snapshotAX(ctx)
+	default:
+		return nil, fmt.Errorf("неизвестный режим snapshot %q (js, ax)", i.mode)
+	}
+}
+
+// snapshotJS runs snapshotScript in the page and in every rendered iframe.
+func (i *Interpreter) snapshotJS() ([]Element, error) {
+	elements, err := snapshotFrame(i.page.MainFrame())
+	if err != nil {
+		return nil, err
+	}
+
+	// Frames are evaluated separately: the page script cannot reach into
+	// cross-origin iframes, Playwright's frame API can.
+	for _, frame := range i.page.Frames() {
+		if frame == i.page.MainFrame() || frame.IsDetached() || len(elements) >= maxElements {
+			continue
+		}
+
+		path, ok := framePath(frame)
+		if !ok {
+			continue
+		}
+
+		inner, err := snapshotFrame(frame)
+		if err != nil {
+			log.Printf("Предупреждение: не удалось прочитать iframe %s: %v", frame.URL(), err)
+			continue
+		}
+		for _, el := range inner {
+			el.Frame = path
+			elements = append(elements, el)
+		}
+	}
+
+	if len(elements) > maxElements {
+		elements = elements[:maxElements]
+	}
+	for n := range elements {
+		elements[n].Index = n
+	}
+
+	return elements, nil
+}
+
+// maxElements caps a snapshot across all frames (snapshotScript stops at
+// the same number). The agent shows the model only the most relevant of
+// them, see memory.max_page_elements.
+const maxElements = 500
+
+func snapshotFrame(frame playwright.Frame) ([]Element, error) {
+	resultHandle, err := frame.EvaluateHandle(snapshotScript)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка evaluate: %w", err)
+	}
+
+	jsonData, err := resultHandle.JSONValue()
+	if err != nil {
+		return nil, fmt.Errorf("JSONValue failed: %w", err)
+	}
+
+	bytes, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var elements []Element
+	err = json.Unmarshal(bytes, &elements)
 	if err != nil {
-		log.Printf("Предупреждение: страница не полностью загрузилась за 15с: %v", err)
+		return nil, fmt.Errorf("unmarshal failed: %w", err)
+	}
+
+	return elements, nil
+}
+
+// framePath returns selectors of the iframe elements leading from the top
+// document to frame, stamping each iframe with a data-agent-id. Frames
+// whose element is not rendered (tracking pixels, hidden widgets) are
+// skipped.
+func framePath(frame playwright.Frame) ([]string, bool) {
+	var path []string
+	for f := frame; f.ParentFrame() != nil; f = f.ParentFrame() {
+		el, err := f.FrameElement()
+		if err != nil {
+			return nil, false
+		}
+		box, err := el.BoundingBox()
+		if err != nil || box == nil || box.Width < 5 || box.Height < 5 {
+			_ = el.Dispose()
+			return nil, false
+		}
+
+		id, err := el.Evaluate(frameIDScript)
+		_ = el.Dispose()
+		if err != nil {
+			return nil, false
+		}
+		path = append([]string{fmt.Sprintf("[data-agent-id=%q]", id)}, path...)
 	}
+	return path, true
+}
 
-	resultHandle, err := i.page.EvaluateHandle(`
+const frameIDScript = `el => {` + agentIDScript + `
+        return agentId(el);
+    }`
+
+// snapshotScript collects interactive elements of one document, including
+// those inside open shadow roots.
+const snapshotScript = `
     () => {
         if (!document.body) {
             return [];
@@ -76,74 +213,124 @@ func (i *Interpreter) Snapshot() ([]Element, error) {
                    style.cursor === "pointer";
         }
 
-        function getBestName(el) {
-            let name = (
-                el.getAttribute("aria-label") ||
-                (el.getAttribute("aria-labelledby") && document.getElementById(el.getAttribute("aria-labelledby"))?.textContent?.trim()) ||
-                el.placeholder ||
-                el.alt ||
-                el.title ||
-                el.textContent?.trim().replace(/\s+/g, " ") ||
-                el.value ||
-                "(без имени)"
-            );
-            return name.slice(0, 100).replace(/[\n\r]+/g, " ");
+` + namesScript + `
+        // isUnique checks sel within el's own document or shadow root;
+        // Playwright's CSS engine pierces open shadow roots the same way.
+        function isUnique(sel, el) {
+            try {
+                const found = el.getRootNode().querySelectorAll(sel);
+                return found.length === 1 && found[0] === el;
+            } catch (e) {
+                return false;
+            }
+        }
+
+        function uniqueId(el) {
+            return el.id && isUnique("#" + CSS.escape(el.id), el) ? "#" + CSS.escape(el.id) : "";
         }
 
-        function buildSimpleSelector(el) {
-            if (!el) return "";
-            
-            if (el.id && /^[a-zA-Z][\w-]*$/.test(el.id)) {
-                return "#" + CSS.escape(el.id);
+        // cssPath returns the shortest selector found that matches only el:
+        // a unique id, a unique identifying attribute, or a tag path with
+        // :nth-of-type anchored at the nearest ancestor with a unique id.
+        function cssPath(el) {
+            const own = uniqueId(el);
+            if (own) return own;
+
+            const tag = el.tagName.toLowerCase();
+            for (const attr of ["data-testid", "data-test", "data-qa", "name", "aria-label", "placeholder", "href"]) {
+                const v = el.getAttribute(attr);
+                if (!v || v.length > 200) continue;
+                const sel = tag + "[" + attr + "=" + JSON.stringify(v) + "]";
+                if (isUnique(sel, el)) return sel;
             }
 
             const parts = [];
             let current = el;
-            let depth = 0;
-
-            while (current && current !== document.body && depth < 7) {
-                let part = current.tagName.toLowerCase();
-                
-                if (current.id && /^[a-zA-Z][\w-]*$/.test(current.id)) {
-                    part += "#" + CSS.escape(current.id);
-                    parts.unshift(part);
-                    break;
-                }
-                
-                if (current.className) {
-                    const classStr = typeof current.className === "string" 
-                        ? current.className 
-                        : current.className.baseVal || "";
-                    
-                    if (classStr) {
-                        const cls = classStr.trim().split(/\s+/).filter(c => c && /^[a-zA-Z_-]/.test(c));
-                        if (cls.length > 0) {
-                            const safeCls = cls.slice(0, 2).map(c => CSS.escape(c));
-                            part += "." + safeCls.join(".");
-                        }
+            while (current && current !== document.documentElement) {
+                if (current !== el) {
+                    const anchor = uniqueId(current);
+                    if (anchor) {
+                        parts.unshift(anchor);
+                        if (isUnique(parts.join(" > "), el)) return parts.join(" > ");
+                        parts.shift();
+                        break;
                     }
                 }
-                
-                if (!current.id) {
-                    const classStr = typeof current.className === "string" 
-                        ? current.className 
-                        : (current.className && current.className.baseVal) || "";
-                    
-                    if (!classStr || !classStr.trim()) {
-                        const siblings = Array.from(current.parentElement?.children || []);
-                        const index = siblings.indexOf(current);
-                        if (index >= 0 && siblings.length > 1) {
-                            part += ":nth-child(" + (index + 1) + ")";
-                        }
+
+                let part = current.tagName.toLowerCase();
+                const parent = current.parentElement;
+                if (parent) {
+                    const same = Array.from(parent.children).filter(c => c.tagName === current.tagName);
+                    if (same.length > 1) {
+                        part += ":nth-of-type(" + (same.indexOf(current) + 1) + ")";
                     }
                 }
-                
                 parts.unshift(part);
-                current = current.parentElement;
-                depth++;
+
+                const sel = parts.join(" > ");
+                if (isUnique(sel, el)) return sel;
+                current = parent;
+            }
+            return parts.join(" > ") || tag;
+        }
+
+        // roleNames counts elements per role and name, built once per snapshot.
+        let roleNames = null;
+        function roleNameCount(role, name) {
+            if (!roleNames) {
+                roleNames = new Map();
+                for (const other of document.querySelectorAll("a[href], button, input, select, textarea, summary, [role]")) {
+                    const r = ariaRole(other);
+                    if (!r) continue;
+                    const key = r + "\u0000" + accessibleName(other);
+                    roleNames.set(key, (roleNames.get(key) || 0) + 1);
+                }
+            }
+            return roleNames.get(role + "\u0000" + name) || 0;
+        }
+
+        function labelText(el) {
+            if (!el.labels || el.labels.length !== 1) return "";
+            const text = el.labels[0].textContent.replace(/\s+/g, " ").trim();
+            if (!text || text.length > 80) return "";
+            const same = Array.from(document.querySelectorAll("label"))
+                .filter(l => l.textContent.replace(/\s+/g, " ").trim() === text);
+            return same.length === 1 ? text : "";
+        }
+
+        // locators lists ways to find el again, each unique on the page at
+        // snapshot time, most robust first; the CSS path always comes last.
+        function locators(el, css) {
+            const out = [];
+            const testid = el.getAttribute("data-testid");
+            if (testid && isUnique("[data-testid=" + JSON.stringify(testid) + "]", el)) {
+                out.push({ kind: "testid", value: testid });
+            }
+            if (el.getRootNode() !== document) {
+                // Role and label lookups are only counted in the light DOM.
+                out.push({ kind: "css", value: css });
+                return out;
+            }
+            const role = ariaRole(el);
+            const name = accessibleName(el);
+            if (role && name && name.length <= 100 && roleNameCount(role, name) === 1) {
+                out.push({ kind: "role", role: role, value: name });
+            }
+            const label = labelText(el);
+            if (label) {
+                out.push({ kind: "label", value: label });
             }
-            
-            return parts.join(" > ") || el.tagName.toLowerCase();
+            out.push({ kind: "css", value: css });
+            return out;
+        }
+
+        // viewportDistance is how far above or below the visible area an
+        // element is, in pixels; 0 when it overlaps the viewport.
+        function viewportDistance(rect) {
+            const height = window.innerHeight || document.documentElement.clientHeight;
+            if (rect.bottom < 0) return Math.round(-rect.bottom);
+            if (rect.top > height) return Math.round(rect.top - height);
+            return 0;
         }
 
         function isInViewport(el) {
@@ -156,17 +343,38 @@ func (i *Interpreter) Snapshot() ([]Element, error) {
             );
         }
 
+` + agentIDScript + `
+
+        function formState(el) {
+            const state = {};
+            const type = (el.type || "").toLowerCase();
+            const role = (el.getAttribute("role") || "").toLowerCase();
+
+            if (el.tagName === "SELECT") {
+                const all = Array.from(el.options);
+                state.options = all.slice(0, 30).map(o => ({
+                    value: o.value,
+                    label: (o.label || o.textContent || "").trim().slice(0, 60),
+                    selected: o.selected
+                }));
+                state.optionsTruncated = all.length > 30 ||
+                    all.some(o => (o.label || o.textContent || "").trim().length > 60);
+            } else if (el.tagName === "INPUT" && (type === "checkbox" || type === "radio")) {
+                state.checked = el.checked;
+            } else if (role === "checkbox" || role === "radio" || role === "switch") {
+                state.checked = el.getAttribute("aria-checked") === "true";
+            }
+
+            return state;
+        }
+
         const elements = [];
         let index = 0;
 
-        const walker = document.createTreeWalker(
-            document.body,
-            NodeFilter.SHOW_ELEMENT,
-            { acceptNode: node => NodeFilter.FILTER_ACCEPT }
-        );
-
-        while (walker.nextNode() && index < 150) {
-            const node = walker.currentNode;
+        // visit walks the tree in document order, descending into open
+        // shadow roots before the host's light children.
+        function visit(node) {
+            if (index >= 500) return;
             if (isInteractive(node)) {
                 const style = window.getComputedStyle(node);
                 const rect = node.getBoundingClientRect();
@@ -176,19 +384,30 @@ func (i *Interpreter) Snapshot() ([]Element, error) {
                                  style.opacity !== "0" &&
                                  rect.width > 0 && rect.height > 0;
 
+                const css = cssPath(node);
                 elements.push({
                     index: index++,
-                    selector: buildSimpleSelector(node),
+                    id: agentId(node),
+                    selector: css,
+                    locators: locators(node, css),
                     role: (node.getAttribute("role") || node.tagName.toLowerCase()),
                     name: getBestName(node),
                     disabled: !!node.disabled,
                     visible: isVisible,
                     isHidden: !isVisible,
-                    inViewport: isInViewport(node)
+                    inViewport: isInViewport(node),
+                    distance: viewportDistance(rect),
+                    ...formState(node)
                 });
             }
+            if (node.shadowRoot) {
+                for (const child of node.shadowRoot.children) visit(child);
+            }
+            for (const child of node.children) visit(child);
         }
 
+        for (const child of document.body.children) visit(child);
+
         if (elements.length === 0) {
             const fallback = document.querySelectorAll("a, button, input, select, textarea, [role=button], [role=link], [tabindex]");
             fallback.forEach(el => {
@@ -201,42 +420,24 @@ func (i *Interpreter) Snapshot() ([]Element, error) {
                                      style.opacity !== "0" &&
                                      rect.width > 0 && rect.height > 0;
 
+                    const css = cssPath(el);
                     elements.push({
                         index: index++,
-                        selector: buildSimpleSelector(el),
+                        id: agentId(el),
+                        selector: css,
+                        locators: locators(el, css),
                         role: (el.getAttribute("role") || el.tagName.toLowerCase()),
                         name: getBestName(el),
                         disabled: !!el.disabled,
                         visible: isVisible,
                         isHidden: !isVisible,
-                        inViewport: isInViewport(el)
+                        inViewport: isInViewport(el),
+                        distance: viewportDistance(rect),
+                        ...formState(el)
                     });
                 }
             });
         }
 
         return elements;
-    }`)
-
-	if err != nil {
-		return nil, fmt.Errorf("ошибка evaluate: %w", err)
-	}
-
-	jsonData, err := resultHandle.JSONValue()
-	if err != nil {
-		return nil, fmt.Errorf("JSONValue failed: %w", err)
-	}
-
-	bytes, err := json.Marshal(jsonData)
-	if err != nil {
-		return nil, err
-	}
-
-	var elements []Element
-	err = json.Unmarshal(bytes, &elements)
-	if err != nil {
-		return nil, fmt.Errorf("unmarshal failed: %w", err)
-	}
-
-	return elements, nil
-}
+    }`
@@ -1,12 +1,74 @@
 package interpreter
 
+import "fmt"
+
 type Element struct {
-	Index      int    `json:"index"`
-	Selector   string `json:"selector"`
-	Role       string `json:"role"`
-	Name       string `json:"name"`
-	Disabled   bool   `json:"disabled"`
-	Visible    bool   `json:"visible"`
-	IsHidden   bool   `json:"isHidden"`
-	InViewport bool   `json:"inViewport"`
+	Index int `json:"index"`
+	// ID is the data-agent-id attribute stamped on the node by Snapshot; it
+	// stays the same across snapshots while the node is in the document.
+	ID string `json:"id"`
+	// Selector is a CSS selector that matched only this element at snapshot
+	// time. The accessibility-tree backend leaves it empty: its only selector
+	// would be the generated ID, which means nothing to the model.
+	Selector string `json:"selector"`
+	// Locators are alternative ways to find the element again, each unique
+	// at snapshot time, most robust first.
+	Locators   []Locator `json:"locators,omitempty"`
+	Role       string    `json:"role"`
+	Name       string    `json:"name"`
+	Disabled   bool      `json:"disabled"`
+	Visible    bool      `json:"visible"`
+	IsHidden   bool      `json:"isHidden"`
+	InViewport bool      `json:"inViewport"`
+	// Distance is how far the element is above or below the viewport, in
+	// pixels; 0 when it is at least partly on screen.
+	Distance int `json:"distance"`
+	// Frame lists selectors of the iframes containing the element, from the
+	// top document down; empty for elements of the page itself.
+	Frame []string `json:"frame,omitempty"`
+	// Checked is set for checkboxes, radios and switches.
+	Checked *bool `json:"checked,omitempty"`
+	// Options lists the choices of a native <select>. OptionsTruncated is
+	// set when some options or the ends of long labels were left out.
+	Options          []Option `json:"options,omitempty"`
+	OptionsTruncated bool     `json:"optionsTruncated,omitempty"`
+
+	// The fields below are filled by the accessibility-tree backend only.
+	Value    string `json:"value,omitempty"`
+	Required bool   `json:"required,omitempty"`
+	Expanded *bool  `json:"expanded,omitempty"`
+	Selected *bool  `json:"selected,omitempty"`
+	// Depth is the element's depth in the accessibility tree and Group the
+	// nearest named container (dialog, navigation, form...).
+	Depth int    `json:"depth,omitempty"`
+	Group string `json:"group,omitempty"`
+}
+
+// Ref is a selector matching exactly this node within its frame, or the CSS
+// selector for elements snapshotted without an id.
+func (el Element) Ref() string {
+	if el.ID == "" {
+		return el.Selector
+	}
+	return fmt.Sprintf("[data-agent-id=%q]", el.ID)
+}
+
+// Locator kinds, tried by the executor in the order Snapshot lists them.
+const (
+	LocatorTestID = "testid" // data-testid attribute, Value is the id
+	LocatorRole   = "role"   // ARIA Role and accessible name in Value
+	LocatorLabel  = "label"  // text of the associated <label>
+	LocatorCSS    = "css"    // CSS selector
+)
+
+type Locator struct {
+	Kind  string `json:"kind"`
+	Role  string `json:"role,omitempty"`
+	Value string `json:"value"`
+}
+
+type Option struct {
+	Value    string `json:"value"`
+	Label    string `json:"label"`
+	Selected bool   `json:"selected"`
 }
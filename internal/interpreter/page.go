@@ -0,0 +1,67 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ai-browser-agent/internal/browser"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// PageState is a short textual summary of the current page.
+type PageState struct {
+	URL   string
+	Title string
+	Text  string
+}
+
+// PageState reads the URL, title and the beginning of the visible text.
+func (i *Interpreter) PageState() PageState {
+	title, _ := i.page.Title()
+
+	visibleText, err := i.page.Locator("body").InnerText()
+	if err == nil {
+		visibleText = strings.ReplaceAll(visibleText, "\n", " ")
+		visibleText = strings.TrimSpace(visibleText)
+		if len(visibleText) > 400 {
+			visibleText = visibleText[:350] + "... (обрезано)"
+		}
+	} else {
+		visibleText = "(не удалось получить текст)"
+	}
+
+	return PageState{
+		URL:   i.page.URL(),
+		Title: title,
+		Text:  visibleText,
+	}
+}
+
+// URL returns the current page URL without touching the page's scripts.
+func (i *Interpreter) URL() string {
+	return i.page.URL()
+}
+
+// ExtractText returns the visible text of el, or of the whole page when el
+// is nil, cut to max characters.
+func (i *Interpreter) ExtractText(ctx context.Context, el *Element, max int) (string, error) {
+	loc := i.page.Locator("body")
+	if el != nil {
+		loc = ScopeOf(i.page, *el).Locator(Locator{Kind: LocatorCSS, Value: el.Ref()})
+	}
+
+	text, err := loc.InnerText(playwright.LocatorInnerTextOptions{
+		Timeout: browser.Timeout(ctx, 5000),
+	})
+	if err != nil {
+		return "", fmt.Errorf("не удалось извлечь текст: %w", err)
+	}
+
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > max {
+		text = string(r[:max]) + "... (обрезано)"
+	}
+	return text, nil
+}
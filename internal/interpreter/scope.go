@@ -0,0 +1,84 @@
+package interpreter
+
+import "github.com/playwright-community/playwright-go"
+
+// Scope resolves locators in the page or, for elements inside iframes, in
+// the frame reached through the element's Frame path.
+type Scope struct {
+	page  playwright.Page
+	frame playwright.FrameLocator
+}
+
+// ScopeOf returns the scope el lives in on page.
+func ScopeOf(page playwright.Page, el Element) Scope {
+	s := Scope{page: page}
+	for _, sel := range el.Frame {
+		if s.frame == nil {
+			s.frame = page.FrameLocator(sel)
+		} else {
+			s.frame = s.frame.FrameLocator(sel)
+		}
+	}
+	return s
+}
+
+// Locator turns a snapshot locator into a Playwright one.
+func (s Scope) Locator(l Locator) playwright.Locator {
+	if s.frame != nil {
+		switch l.Kind {
+		case LocatorTestID:
+			return s.frame.GetByTestId(l.Value)
+		case LocatorRole:
+			return s.frame.GetByRole(playwright.AriaRole(l.Role), playwright.FrameLocatorGetByRoleOptions{
+				Name:  l.Value,
+				Exact: playwright.Bool(true),
+			})
+		case LocatorLabel:
+			return s.frame.GetByLabel(l.Value, playwright.FrameLocatorGetByLabelOptions{Exact: playwright.Bool(true)})
+		default:
+			return s.frame.Locator(l.Value)
+		}
+	}
+
+	switch l.Kind {
+	case LocatorTestID:
+		return s.page.GetByTestId(l.Value)
+	case LocatorRole:
+		return s.page.GetByRole(playwright.AriaRole(l.Role), playwright.PageGetByRoleOptions{
+			Name:  l.Value,
+			Exact: playwright.Bool(true),
+		})
+	case LocatorLabel:
+		return s.page.GetByLabel(l.Value, playwright.PageGetByLabelOptions{Exact: playwright.Bool(true)})
+	default:
+		return s.page.Locator(l.Value)
+	}
+}
+
+// SameElement reports whether the single node loc matches still has el's
+// role and name, as either snapshot backend computed them.
+func SameElement(loc playwright.Locator, el Element) (bool, error) {
+	raw, err := loc.Evaluate(identityScript, nil, playwright.LocatorEvaluateOptions{Timeout: playwright.Float(2000)})
+	if err != nil {
+		return false, err
+	}
+	got, _ := raw.(map[string]interface{})
+	str := func(key string) string {
+		s, _ := got[key].(string)
+		return s
+	}
+
+	name := el.Name
+	if name == "(без имени)" {
+		name = ""
+	}
+	named := func(s string) string {
+		if s == "(без имени)" {
+			return ""
+		}
+		return s
+	}
+
+	return (str("role") == el.Role && named(str("name")) == name) ||
+		(str("ariaRole") == el.Role && str("accessibleName") == name), nil
+}
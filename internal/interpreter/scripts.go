@@ -0,0 +1,86 @@
+package interpreter
+
+// agentIDScript declares agentId(el), which stamps el with a data-agent-id
+// unless it has one. Each node keeps its id for as long as it stays in the
+// document, so an action can be resolved against the snapshot it was
+// chosen from. The per-document prefix keeps ids from a previous page from
+// matching nodes of the next one.
+const agentIDScript = `
+        function agentId(el) {
+            let id = el.getAttribute("data-agent-id");
+            if (!id) {
+                window.__agentDoc = window.__agentDoc || Math.random().toString(36).slice(2, 8);
+                window.__agentSeq = (window.__agentSeq || 0) + 1;
+                id = window.__agentDoc + "-" + window.__agentSeq;
+                el.setAttribute("data-agent-id", id);
+            }
+            return id;
+        }
+`
+
+// namesScript declares getBestName(el), the name the walker shows, and
+// ariaRole(el) / accessibleName(el), the role and name getByRole matches.
+const namesScript = `
+        function getBestName(el) {
+            let name = (
+                el.getAttribute("aria-label") ||
+                (el.getAttribute("aria-labelledby") && document.getElementById(el.getAttribute("aria-labelledby"))?.textContent?.trim()) ||
+                el.placeholder ||
+                el.alt ||
+                el.title ||
+                el.textContent?.trim().replace(/\s+/g, " ") ||
+                el.value ||
+                "(без имени)"
+            );
+            return name.slice(0, 100).replace(/[\n\r]+/g, " ");
+        }
+
+        const implicitRoles = {
+            A: el => el.hasAttribute("href") ? "link" : "",
+            BUTTON: () => "button",
+            SELECT: el => el.multiple || el.size > 1 ? "listbox" : "combobox",
+            TEXTAREA: () => "textbox",
+            SUMMARY: () => "button",
+            INPUT: el => ({
+                button: "button", submit: "button", reset: "button", image: "button",
+                checkbox: "checkbox", radio: "radio", range: "slider", number: "spinbutton",
+                search: "searchbox", email: "textbox", tel: "textbox", text: "textbox",
+                url: "textbox", password: "textbox", "": "textbox"
+            })[(el.getAttribute("type") || "").toLowerCase()] || ""
+        };
+
+        function ariaRole(el) {
+            const explicit = (el.getAttribute("role") || "").trim().split(/\s+/)[0];
+            if (explicit) return explicit.toLowerCase();
+            const f = implicitRoles[el.tagName];
+            return f ? f(el) : "";
+        }
+
+        // accessibleName approximates the name Playwright's getByRole
+        // matches; the executor checks the locator again before using it.
+        function accessibleName(el) {
+            const by = el.getAttribute("aria-labelledby");
+            const name =
+                el.getAttribute("aria-label") ||
+                (by && by.split(/\s+/).map(id => document.getElementById(id)?.textContent || "").join(" ")) ||
+                (el.labels && el.labels.length ? el.labels[0].textContent : "") ||
+                el.getAttribute("alt") ||
+                (["INPUT", "SELECT", "TEXTAREA"].includes(el.tagName) ? "" : el.textContent) ||
+                el.getAttribute("title") ||
+                el.getAttribute("placeholder") ||
+                "";
+            return name.replace(/\s+/g, " ").trim();
+        }
+`
+
+// identityScript reports the role and name of a node the way both snapshot
+// backends compute them, so a node found by a fallback locator can be
+// compared with the element that was snapshotted.
+const identityScript = `el => {` + namesScript + `
+        return {
+            role: el.getAttribute("role") || el.tagName.toLowerCase(),
+            name: getBestName(el),
+            ariaRole: ariaRole(el),
+            accessibleName: accessibleName(el)
+        };
+    }`
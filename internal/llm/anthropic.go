@@ -0,0 +1,137 @@
+package llm
+
+import (
+	"ai-browser-agent/internal/agent/promts"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"ai-browser-agent/internal/config"
+	"ai-browser-agent/internal/core"
+)
+
+const anthropicVersion = "2023-06-01"
+
+// AnthropicClient talks to the native Anthropic Messages API.
+type AnthropicClient struct {
+	apiKey       string
+	baseURL      string
+	model        string
+	maxTokens    int
+	temperature  float32
+	mode         string
+	parseRetries int
+	http         *transport
+}
+
+func NewAnthropic(cfg *config.Config) Client {
+	mode := cfg.LLM.Mode
+	if mode == "" {
+		mode = ModeJSON
+	}
+
+	return &AnthropicClient{
+		apiKey:       cfg.Env.AnthropicAPIKey,
+		baseURL:      strings.TrimRight(cfg.Env.AnthropicBaseURL, "/"),
+		model:        cfg.LLM.Model,
+		maxTokens:    cfg.LLM.MaxTokens,
+		temperature:  cfg.LLM.Temperature,
+		mode:         mode,
+		parseRetries: parseRetries(cfg),
+		http:         newTransport("anthropic", cfg),
+	}
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func (c *AnthropicClient) NextAction(ctx context.Context, fullPrompt string) (*Response, error) {
+	return reask(ctx, c.http.provider, c.model, c.parseRetries, fullPrompt, c.ask)
+}
+
+func (c *AnthropicClient) ask(ctx context.Context, fullPrompt string, resp *Response) (*core.Action, error) {
+	reqBody := map[string]interface{}{
+		"model":       c.model,
+		"system":      promts.System(c.mode == ModeTools),
+		"max_tokens":  c.maxTokens,
+		"temperature": c.temperature,
+		"messages": []map[string]string{
+			{
+				"role":    "user",
+				"content": fullPrompt,
+			},
+		},
+	}
+
+	if c.mode == ModeTools {
+		reqBody["tools"] = anthropicTools()
+		reqBody["tool_choice"] = map[string]string{"type": "any"}
+	}
+
+	headers := map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+
+	var apiResp anthropicResponse
+	attempts, err := c.http.postJSON(ctx, c.baseURL+"/messages", headers, reqBody, &apiResp)
+	resp.Attempts += attempts
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Usage.Add(Usage{
+		PromptTokens:     apiResp.Usage.InputTokens,
+		CompletionTokens: apiResp.Usage.OutputTokens,
+	})
+
+	var text strings.Builder
+	var actions []*core.Action
+	for _, block := range apiResp.Content {
+		switch block.Type {
+		case "tool_use":
+			action, err := actionFromCall(block.Name, block.Input)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, action)
+		case "text":
+			text.WriteString(block.Text)
+		}
+	}
+	if len(actions) > 0 {
+		return batchOf(actions)
+	}
+
+	if text.Len() == 0 {
+		return nil, parseError(fmt.Errorf("empty response (stop_reason=%s)", apiResp.StopReason))
+	}
+
+	action, err := parseAction(text.String())
+	return action, err
+}
+
+// anthropicTools renders actionTools in the Messages API "tools" format.
+func anthropicTools() []map[string]interface{} {
+	tools := make([]map[string]interface{}, 0, len(actionTools))
+	for _, t := range actionTools {
+		tools = append(tools, map[string]interface{}{
+			"name":         t.Name,
+			"description":  t.Description,
+			"input_schema": t.Parameters,
+		})
+	}
+	return tools
+}
@@ -0,0 +1,194 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"ai-browser-agent/internal/config"
+	"ai-browser-agent/internal/core"
+)
+
+// apiStub stands in for a provider API: it records each request and
+// answers with the next reply body.
+type apiStub struct {
+	t        *testing.T
+	replies  []string
+	requests []map[string]interface{}
+	headers  []http.Header
+	paths    []string
+}
+
+func (s *apiStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.t.Errorf("request body: %v", err)
+	}
+	s.requests = append(s.requests, body)
+	s.headers = append(s.headers, r.Header.Clone())
+	s.paths = append(s.paths, r.URL.Path)
+
+	if len(s.replies) == 0 {
+		s.t.Error("unexpected extra request")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(reply))
+}
+
+func newAnthropicStub(t *testing.T, mode string, replies ...string) (*apiStub, Client) {
+	t.Helper()
+	stub := &apiStub{t: t, replies: replies}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		LLM: config.LLMConfig{Model: "claude-test", MaxTokens: 512, Mode: mode, ParseRetries: 1},
+		Env: config.EnvConfig{AnthropicAPIKey: "test-key", AnthropicBaseURL: srv.URL + "/v1/"},
+	}
+	return stub, NewAnthropic(cfg)
+}
+
+func TestAnthropicToolUse(t *testing.T) {
+	stub, client := newAnthropicStub(t, ModeTools, `{
+		"content": [
+			{"type": "text", "text": "Нажимаю на кнопку поиска"},
+			{"type": "tool_use", "id": "t1", "name": "click", "input": {"target": 3, "reason": "search"}}
+		],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 120, "output_tokens": 15}
+	}`)
+
+	resp, err := client.NextAction(context.Background(), "GOAL: найти")
+	if err != nil {
+		t.Fatalf("NextAction() = %v", err)
+	}
+
+	want := &core.Action{Type: core.ActionClick, Target: 3, Reason: "search"}
+	if !reflect.DeepEqual(resp.Action, want) {
+		t.Errorf("action = %+v, want %+v", resp.Action, want)
+	}
+	if resp.Provider != "anthropic" || resp.Model != "claude-test" || resp.Attempts != 1 {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp.Usage != (Usage{PromptTokens: 120, CompletionTokens: 15}) {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+
+	if got := stub.paths[0]; got != "/v1/messages" {
+		t.Errorf("path = %q, want /v1/messages", got)
+	}
+	h := stub.headers[0]
+	if h.Get("x-api-key") != "test-key" {
+		t.Errorf("x-api-key = %q", h.Get("x-api-key"))
+	}
+	if h.Get("anthropic-version") != anthropicVersion {
+		t.Errorf("anthropic-version = %q, want %q", h.Get("anthropic-version"), anthropicVersion)
+	}
+	if h.Get("Authorization") != "" {
+		t.Errorf("unexpected Authorization header %q", h.Get("Authorization"))
+	}
+
+	req := stub.requests[0]
+	if req["model"] != "claude-test" || req["max_tokens"] != float64(512) {
+		t.Errorf("request model/max_tokens = %v/%v", req["model"], req["max_tokens"])
+	}
+	if tools, _ := req["tools"].([]interface{}); len(tools) != len(actionTools) {
+		t.Errorf("request declares %d tools, want %d", len(tools), len(actionTools))
+	}
+	if choice, _ := req["tool_choice"].(map[string]interface{}); choice["type"] != "any" {
+		t.Errorf("tool_choice = %v, want any", req["tool_choice"])
+	}
+}
+
+func TestAnthropicToolUseBatch(t *testing.T) {
+	_, client := newAnthropicStub(t, ModeTools, `{
+		"content": [
+			{"type": "tool_use", "id": "t1", "name": "type", "input": {"target": 1, "text": "iphone"}},
+			{"type": "tool_use", "id": "t2", "name": "press_key", "input": {"key": "Enter"}}
+		],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 10, "output_tokens": 5}
+	}`)
+
+	resp, err := client.NextAction(context.Background(), "p")
+	if err != nil {
+		t.Fatalf("NextAction() = %v", err)
+	}
+	a := resp.Action
+	if a.Type != core.ActionBatch || len(a.Actions) != 2 {
+		t.Fatalf("action = %+v, want a batch of 2", a)
+	}
+	if a.Actions[0].Type != core.ActionTypeText || a.Actions[0].Target != 1 || a.Actions[0].Text != "iphone" {
+		t.Errorf("first = %+v", a.Actions[0])
+	}
+	if a.Actions[1].Type != core.ActionPressKey || a.Actions[1].HasTarget() || a.Actions[1].Key != "Enter" {
+		t.Errorf("second = %+v", a.Actions[1])
+	}
+}
+
+func TestAnthropicTextFallback(t *testing.T) {
+	stub, client := newAnthropicStub(t, ModeJSON, `{
+		"content": [
+			{"type": "text", "text": "Вот действие:\n`+"```json"+`\n{\"type\": \"navigate\", \"url\": \"https://example.com\"}\n`+"```"+`"}
+		],
+		"stop_reason": "end_turn",
+		"usage": {"input_tokens": 50, "output_tokens": 20}
+	}`)
+
+	resp, err := client.NextAction(context.Background(), "p")
+	if err != nil {
+		t.Fatalf("NextAction() = %v", err)
+	}
+	if resp.Action.Type != core.ActionNavigate || resp.Action.URL != "https://example.com" || resp.Action.HasTarget() {
+		t.Errorf("action = %+v", resp.Action)
+	}
+	if _, ok := stub.requests[0]["tools"]; ok {
+		t.Error("JSON mode request declares tools")
+	}
+}
+
+func TestAnthropicReasksUnparsableText(t *testing.T) {
+	stub, client := newAnthropicStub(t, ModeJSON,
+		`{"content": [{"type": "text", "text": "Сначала подумаю..."}], "stop_reason": "end_turn", "usage": {"input_tokens": 10, "output_tokens": 3}}`,
+		`{"content": [{"type": "text", "text": "{\"type\": \"go_back\"}"}], "stop_reason": "end_turn", "usage": {"input_tokens": 12, "output_tokens": 4}}`,
+	)
+
+	resp, err := client.NextAction(context.Background(), "p")
+	if err != nil {
+		t.Fatalf("NextAction() = %v", err)
+	}
+	if resp.Action.Type != core.ActionBack || resp.Reasks != 1 || resp.Attempts != 2 {
+		t.Errorf("action = %+v, reasks = %d, attempts = %d", resp.Action, resp.Reasks, resp.Attempts)
+	}
+	if resp.Usage != (Usage{PromptTokens: 22, CompletionTokens: 7}) {
+		t.Errorf("usage = %+v, want both requests summed", resp.Usage)
+	}
+	if len(stub.requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(stub.requests))
+	}
+}
+
+func TestAnthropicRejectsBatchedDone(t *testing.T) {
+	_, client := newAnthropicStub(t, ModeTools,
+		`{"content": [
+			{"type": "tool_use", "id": "t1", "name": "click", "input": {"target": 2}},
+			{"type": "tool_use", "id": "t2", "name": "done", "input": {"answer": "готово"}}
+		], "stop_reason": "tool_use", "usage": {"input_tokens": 1, "output_tokens": 1}}`,
+		`{"content": [{"type": "tool_use", "id": "t3", "name": "click", "input": {"target": 2}}], "stop_reason": "tool_use", "usage": {"input_tokens": 1, "output_tokens": 1}}`,
+	)
+
+	resp, err := client.NextAction(context.Background(), "p")
+	if err != nil {
+		t.Fatalf("NextAction() = %v", err)
+	}
+	if resp.Reasks != 1 || resp.Action.Type != core.ActionClick {
+		t.Errorf("action = %+v after %d reasks, want a click after 1", resp.Action, resp.Reasks)
+	}
+}
@@ -0,0 +1,208 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"ai-browser-agent/internal/core"
+)
+
+const (
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+)
+
+// ErrCassetteMiss is returned by the replayer for a prompt it has never
+// seen, or has already served every recorded reply for.
+var ErrCassetteMiss = errors.New("cassette: no recording for prompt")
+
+// cassetteEntry is one recorded reply. The prompt excerpt is stored only to
+// make cassette diffs readable.
+type cassetteEntry struct {
+	Prompt   string       `json:"prompt"`
+	Action   *core.Action `json:"action"`
+	Provider string       `json:"provider"`
+	Model    string       `json:"model"`
+	Usage    Usage        `json:"usage"`
+	Failed   []ModelUsage `json:"failed,omitempty"`
+}
+
+// cassette maps a prompt hash to the replies recorded for it, in call order:
+// the same prompt may legitimately come up more than once in a run.
+type cassette struct {
+	Entries map[string][]cassetteEntry `json:"entries"`
+}
+
+// loadCassette reads and checks a cassette file.
+func loadCassette(path string) (cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cassette{}, fmt.Errorf("cassette: read %s: %w", path, err)
+	}
+
+	var data cassette
+	if err := json.Unmarshal(b, &data); err != nil {
+		return cassette{}, fmt.Errorf("cassette: parse %s: %w", path, err)
+	}
+	if data.Entries == nil {
+		data.Entries = map[string][]cassetteEntry{}
+	}
+	for key, entries := range data.Entries {
+		for i, e := range entries {
+			if e.Action == nil || e.Action.Type == "" {
+				return cassette{}, fmt.Errorf("cassette: %s: entry %d of %s has no action", path, i, key)
+			}
+		}
+	}
+	return data, nil
+}
+
+// cloneAction deep-copies an action so callers cannot modify a recording.
+func cloneAction(a *core.Action) (*core.Action, error) {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	var out core.Action
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func promptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// Recorder forwards calls to the wrapped client and stores every successful
+// reply in a cassette file, rewritten after each call so a crashed run still
+// leaves a usable recording. Recordings already in the file are kept,
+// except for prompts recorded again, whose old replies are replaced.
+type Recorder struct {
+	next Client
+	path string
+	mu   sync.Mutex
+	data cassette
+	// recorded marks prompts this recorder has stored a reply for.
+	recorded map[string]bool
+}
+
+func NewRecorder(next Client, path string) (*Recorder, error) {
+	data := cassette{Entries: map[string][]cassetteEntry{}}
+	if _, err := os.Stat(path); err == nil {
+		if data, err = loadCassette(path); err != nil {
+			return nil, err
+		}
+	}
+	return &Recorder{next: next, path: path, data: data, recorded: map[string]bool{}}, nil
+}
+
+func (r *Recorder) NextAction(ctx context.Context, prompt string) (*Response, error) {
+	resp, err := r.next.NextAction(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	action, err := cloneAction(resp.Action)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+
+	key := promptHash(prompt)
+	if !r.recorded[key] {
+		r.recorded[key] = true
+		delete(r.data.Entries, key)
+	}
+	r.data.Entries[key] = append(r.data.Entries[key], cassetteEntry{
+		Prompt:   truncate(prompt, 300),
+		Action:   action,
+		Provider: resp.Provider,
+		Model:    resp.Model,
+		Usage:    resp.Usage,
+		Failed:   resp.Failed,
+	})
+
+	if err := r.save(); err != nil {
+		return nil, fmt.Errorf("cassette: save %s: %w", r.path, err)
+	}
+
+	return resp, nil
+}
+
+// SetFailureStreak keeps routing working when recording a routed client.
+func (r *Recorder) SetFailureStreak(n int) {
+	if e, ok := r.next.(Escalator); ok {
+		e.SetFailureStreak(n)
+	}
+}
+
+func (r *Recorder) save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(r.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path, b, 0o644)
+}
+
+// Replayer serves recorded replies offline. Repeated prompts get the
+// recorded replies in order; asking once more than recorded is a miss.
+type Replayer struct {
+	mu   sync.Mutex
+	data cassette
+	seen map[string]int
+}
+
+func NewReplayer(path string) (*Replayer, error) {
+	data, err := loadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Replayer{data: data, seen: map[string]int{}}, nil
+}
+
+func (r *Replayer) NextAction(ctx context.Context, prompt string) (*Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := promptHash(prompt)
+	entries := r.data.Entries[key]
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w %s: %q", ErrCassetteMiss, key[:12], truncate(prompt, 300))
+	}
+
+	i := r.seen[key]
+	if i >= len(entries) {
+		return nil, fmt.Errorf("%w %s: all %d recorded replies already used", ErrCassetteMiss, key[:12], len(entries))
+	}
+	r.seen[key]++
+
+	e := entries[i]
+	action, err := cloneAction(e.Action)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+
+	return &Response{
+		Action:   action,
+		Provider: e.Provider,
+		Model:    e.Model,
+		Usage:    e.Usage,
+		Failed:   append([]ModelUsage(nil), e.Failed...),
+	}, nil
+}
@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"ai-browser-agent/internal/core"
+)
+
+// scriptedClient answers with the given actions in order.
+type scriptedClient struct {
+	actions []*core.Action
+}
+
+func (c *scriptedClient) NextAction(ctx context.Context, prompt string) (*Response, error) {
+	if len(c.actions) == 0 {
+		return nil, errors.New("no more actions")
+	}
+	a := c.actions[0]
+	c.actions = c.actions[1:]
+	return &Response{Action: a, Provider: "test", Model: "m", Usage: Usage{PromptTokens: 10, CompletionTokens: 2}}, nil
+}
+
+func TestCassetteRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	to := 4
+	batch := &core.Action{Type: core.ActionBatch, Target: core.NoTarget, Actions: []core.Action{
+		{Type: core.ActionTypeText, Target: 3, Text: "iphone"},
+		{Type: core.ActionDrag, Target: 0, To: &to},
+	}}
+	click := &core.Action{Type: core.ActionClick, Target: 0}
+	done := &core.Action{Type: core.ActionDone, Target: core.NoTarget, Answer: "ok"}
+
+	rec, err := NewRecorder(&scriptedClient{actions: []*core.Action{batch, click, done}}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, prompt := range []string{"step 1", "step 1", "step 2"} {
+		if _, err := rec.NextAction(ctx, prompt); err != nil {
+			t.Fatalf("record %q: %v", prompt, err)
+		}
+	}
+
+	rep, err := NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		prompt string
+		want   *core.Action
+	}{
+		{"step 1", batch},
+		{"step 1", click},
+		{"step 2", done},
+	} {
+		resp, err := rep.NextAction(ctx, tc.prompt)
+		if err != nil {
+			t.Fatalf("replay %q: %v", tc.prompt, err)
+		}
+		if !reflect.DeepEqual(resp.Action, tc.want) {
+			t.Errorf("replay %q = %+v, want %+v", tc.prompt, resp.Action, tc.want)
+		}
+		if resp.Provider != "test" || resp.Model != "m" || resp.Usage.PromptTokens != 10 {
+			t.Errorf("replay %q: response metadata not restored: %+v", tc.prompt, resp)
+		}
+	}
+
+	if _, err := rep.NextAction(ctx, "step 2"); !errors.Is(err, ErrCassetteMiss) {
+		t.Errorf("replay past the recorded replies: err = %v, want ErrCassetteMiss", err)
+	}
+	if _, err := rep.NextAction(ctx, "unknown"); !errors.Is(err, ErrCassetteMiss) {
+		t.Errorf("replay unknown prompt: err = %v, want ErrCassetteMiss", err)
+	}
+}
+
+func TestReplayerReturnsCopies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	batch := &core.Action{Type: core.ActionBatch, Target: core.NoTarget, Actions: []core.Action{{Type: core.ActionClick, Target: 1}}}
+
+	rec, err := NewRecorder(&scriptedClient{actions: []*core.Action{batch}}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.NextAction(context.Background(), "p"); err != nil {
+		t.Fatal(err)
+	}
+	batch.Actions[0].Target = 9 // the recording must not change
+
+	rep, err := NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.data.Entries[promptHash("p")] = append(rep.data.Entries[promptHash("p")], rep.data.Entries[promptHash("p")][0])
+
+	first, err := rep.NextAction(context.Background(), "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Action.Actions[0].Target != 1 {
+		t.Fatalf("recorded target = %d, want 1", first.Action.Actions[0].Target)
+	}
+	first.Action.Actions[0].Target = 7
+
+	second, err := rep.NextAction(context.Background(), "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Action.Actions[0].Target != 1 {
+		t.Errorf("replayed action shares state with an earlier reply: target = %d", second.Action.Actions[0].Target)
+	}
+}
+
+func TestRecorderKeepsOtherPrompts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	ctx := context.Background()
+
+	first, err := NewRecorder(&scriptedClient{actions: []*core.Action{
+		{Type: core.ActionClick, Target: 1},
+		{Type: core.ActionClick, Target: 2},
+	}}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.NextAction(ctx, "a")
+	first.NextAction(ctx, "b")
+
+	second, err := NewRecorder(&scriptedClient{actions: []*core.Action{{Type: core.ActionClick, Target: 3}}}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.NextAction(ctx, "b")
+
+	rep, err := NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for prompt, want := range map[string]int{"a": 1, "b": 3} {
+		resp, err := rep.NextAction(ctx, prompt)
+		if err != nil {
+			t.Fatalf("replay %q: %v", prompt, err)
+		}
+		if resp.Action.Target != want {
+			t.Errorf("replay %q: target = %d, want %d", prompt, resp.Action.Target, want)
+		}
+	}
+}
+
+func TestReplayerRejectsMalformedCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	if err := os.WriteFile(path, []byte(`{"entries": {"abc": [{"prompt": "p"}]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewReplayer(path); err == nil {
+		t.Error("NewReplayer accepted an entry without an action")
+	}
+}
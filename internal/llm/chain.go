@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// Chain tries each client in order and fails over to the next one when a
+// call ends with an error another model may not hit: rate limit, outage,
+// network failure or garbage output. Auth and bad-request errors are
+// configuration problems and are returned at once.
+type Chain struct {
+	clients []Client
+}
+
+func NewChain(clients ...Client) Client {
+	if len(clients) == 1 {
+		return clients[0]
+	}
+	return &Chain{clients: clients}
+}
+
+func (c *Chain) NextAction(ctx context.Context, prompt string) (*Response, error) {
+	var lastErr *Error
+	attempts := 0
+	// failed keeps each skipped model's tokens apart for pricing.
+	var failed []ModelUsage
+
+	for i, client := range c.clients {
+		resp, err := client.NextAction(ctx, prompt)
+		if err == nil {
+			resp.Attempts += attempts
+			resp.Failed = append(failed, resp.Failed...)
+			resp.Failovers = i
+			return resp, nil
+		}
+
+		var e *Error
+		if !errors.As(err, &e) || e.Kind == KindCanceled {
+			return nil, err
+		}
+
+		attempts += e.Attempts
+		e.Failed = append(failed, e.Failed...)
+		lastErr = e
+		if !e.Retryable() && e.Kind != KindParse {
+			break
+		}
+		failed = e.Spent()
+		if i < len(c.clients)-1 {
+			log.Printf("LLM %s/%s недоступна (%s), переключаюсь на резервную модель", e.Provider, e.Model, e.Kind)
+		}
+	}
+
+	lastErr.Attempts = attempts
+	return nil, lastErr
+}
+
+// Escalator is implemented by clients that switch to a stronger model after
+// repeated execution failures. The agent reports its current failure streak
+// before each call.
+type Escalator interface {
+	SetFailureStreak(n int)
+}
+
+// Router sends steps to a cheap model and escalates to a strong one once the
+// agent has failed EscalateAfter times in a row.
+type Router struct {
+	cheap         Client
+	strong        Client
+	escalateAfter int
+	streak        int
+}
+
+func NewRouter(cheap, strong Client, escalateAfter int) *Router {
+	if escalateAfter <= 0 {
+		escalateAfter = 2
+	}
+	return &Router{cheap: cheap, strong: strong, escalateAfter: escalateAfter}
+}
+
+func (r *Router) SetFailureStreak(n int) {
+	r.streak = n
+}
+
+func (r *Router) NextAction(ctx context.Context, prompt string) (*Response, error) {
+	if r.streak >= r.escalateAfter {
+		return r.strong.NextAction(ctx, prompt)
+	}
+	return r.cheap.NextAction(ctx, prompt)
+}
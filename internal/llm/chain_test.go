@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"ai-browser-agent/internal/core"
+)
+
+// stubClient fails with err, or answers with a done action when err is nil.
+type stubClient struct {
+	model string
+	err   *Error
+	calls int
+}
+
+func (c *stubClient) NextAction(ctx context.Context, prompt string) (*Response, error) {
+	c.calls++
+	usage := Usage{PromptTokens: 100, CompletionTokens: 10}
+	if c.err != nil {
+		e := *c.err
+		e.Provider, e.Model, e.Usage = "test", c.model, usage
+		return nil, &e
+	}
+	return &Response{Action: &core.Action{Type: core.ActionDone}, Provider: "test", Model: c.model, Attempts: 1, Usage: usage}, nil
+}
+
+func failing(model string, kind ErrorKind, attempts int) *stubClient {
+	return &stubClient{model: model, err: &Error{Kind: kind, Attempts: attempts, Err: errors.New(string(kind))}}
+}
+
+func TestChainFailsOver(t *testing.T) {
+	for _, kind := range []ErrorKind{KindServer, KindRateLimit, KindNetwork, KindParse} {
+		t.Run(string(kind), func(t *testing.T) {
+			first := failing("a", kind, 3)
+			second := &stubClient{model: "b"}
+
+			resp, err := NewChain(first, second).NextAction(context.Background(), "p")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Model != "b" || resp.Failovers != 1 {
+				t.Errorf("answered by %s after %d failovers, want b after 1", resp.Model, resp.Failovers)
+			}
+			if resp.Attempts != 4 {
+				t.Errorf("attempts = %d, want 3 + 1", resp.Attempts)
+			}
+			want := []ModelUsage{{Model: "a", Usage: Usage{PromptTokens: 100, CompletionTokens: 10}}}
+			if !reflect.DeepEqual(resp.Failed, want) {
+				t.Errorf("failed = %+v, want %+v", resp.Failed, want)
+			}
+		})
+	}
+}
+
+func TestChainStopsOnConfigErrors(t *testing.T) {
+	for _, kind := range []ErrorKind{KindAuth, KindBadRequest, KindCanceled} {
+		t.Run(string(kind), func(t *testing.T) {
+			first := failing("a", kind, 1)
+			second := &stubClient{model: "b"}
+
+			_, err := NewChain(first, second).NextAction(context.Background(), "p")
+			if KindOf(err) != kind {
+				t.Errorf("error = %v, want %s", err, kind)
+			}
+			if second.calls != 0 {
+				t.Errorf("fallback called %d times after %s", second.calls, kind)
+			}
+		})
+	}
+}
+
+func TestChainAllFail(t *testing.T) {
+	chain := NewChain(failing("a", KindServer, 3), failing("b", KindRateLimit, 2), failing("c", KindParse, 1))
+
+	_, err := chain.NextAction(context.Background(), "p")
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("error = %v, want *Error", err)
+	}
+	if e.Kind != KindParse || e.Model != "c" {
+		t.Errorf("error from %s (%s), want the last model's", e.Model, e.Kind)
+	}
+	if e.Attempts != 6 {
+		t.Errorf("attempts = %d, want 3 + 2 + 1", e.Attempts)
+	}
+
+	var models []string
+	for _, u := range e.Spent() {
+		models = append(models, u.Model)
+	}
+	if !reflect.DeepEqual(models, []string{"a", "b", "c"}) {
+		t.Errorf("spent by %v, want every model once", models)
+	}
+}
+
+func TestNewChainSingleClient(t *testing.T) {
+	only := &stubClient{model: "a"}
+	if c := NewChain(only); c != Client(only) {
+		t.Errorf("NewChain of one client = %T, want the client itself", c)
+	}
+}
+
+func TestRouterEscalates(t *testing.T) {
+	cheap, strong := &stubClient{model: "cheap"}, &stubClient{model: "strong"}
+	r := NewRouter(cheap, strong, 2)
+
+	for streak, want := range []string{"cheap", "cheap", "strong", "strong"} {
+		r.SetFailureStreak(streak)
+		resp, err := r.NextAction(context.Background(), "p")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Model != want {
+			t.Errorf("streak %d: answered by %s, want %s", streak, resp.Model, want)
+		}
+	}
+
+	// A success resets the streak and the cheap model takes over again.
+	r.SetFailureStreak(0)
+	if resp, _ := r.NextAction(context.Background(), "p"); resp.Model != "cheap" {
+		t.Errorf("after reset answered by %s, want cheap", resp.Model)
+	}
+}
+
+func TestRouterDefaultEscalateAfter(t *testing.T) {
+	r := NewRouter(&stubClient{model: "cheap"}, &stubClient{model: "strong"}, 0)
+	if r.escalateAfter != 2 {
+		t.Errorf("escalateAfter = %d, want the default 2", r.escalateAfter)
+	}
+}
@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorKind classifies why a model call failed.
+type ErrorKind string
+
+const (
+	KindRateLimit  ErrorKind = "rate_limit"
+	KindAuth       ErrorKind = "auth"
+	KindServer     ErrorKind = "server"
+	KindNetwork    ErrorKind = "network"
+	KindBadRequest ErrorKind = "bad_request"
+	KindParse      ErrorKind = "parse"
+	KindCanceled   ErrorKind = "canceled"
+)
+
+// Error is returned by every Client once retries are exhausted.
+type Error struct {
+	Kind     ErrorKind
+	Provider string
+	Model    string
+	Status   int
+	Attempts int
+	// Usage counts tokens Model spent before the call gave up, and Failed
+	// those of models a Chain tried before it.
+	Usage  Usage
+	Failed []ModelUsage
+	Err    error
+}
+
+// Spent lists the tokens every model spent before the call failed.
+func (e *Error) Spent() []ModelUsage {
+	return append(append([]ModelUsage(nil), e.Failed...), ModelUsage{Model: e.Model, Usage: e.Usage})
+}
+
+func (e *Error) Error() string {
+	if e.Status != 0 {
+		return fmt.Sprintf("%s/%s: %s (status %d, attempts %d): %v", e.Provider, e.Model, e.Kind, e.Status, e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("%s/%s: %s (attempts %d): %v", e.Provider, e.Model, e.Kind, e.Attempts, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the same request may succeed if sent again.
+func (e *Error) Retryable() bool {
+	switch e.Kind {
+	case KindRateLimit, KindServer, KindNetwork:
+		return true
+	default:
+		return false
+	}
+}
+
+// KindOf returns the classification of err, or "" if it is not an *Error.
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return ""
+}
+
+func classifyStatus(code int) ErrorKind {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return KindRateLimit
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return KindAuth
+	case code >= 500:
+		return KindServer
+	default:
+		return KindBadRequest
+	}
+}
+
+func parseError(err error) error {
+	return &Error{Kind: KindParse, Err: err}
+}
@@ -0,0 +1,170 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-browser-agent/internal/config"
+)
+
+const (
+	defaultTimeout     = 60 * time.Second
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 500 * time.Millisecond
+	defaultMaxDelay    = 10 * time.Second
+	defaultParseRetry  = 1
+)
+
+// transport posts JSON to a provider endpoint with timeouts and retries.
+type transport struct {
+	provider    string
+	client      *http.Client
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+func parseRetries(cfg *config.Config) int {
+	if cfg.LLM.ParseRetries > 0 {
+		return cfg.LLM.ParseRetries
+	}
+	return defaultParseRetry
+}
+
+func newTransport(provider string, cfg *config.Config) *transport {
+	t := &transport{
+		provider:    provider,
+		client:      &http.Client{Timeout: defaultTimeout},
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+		maxDelay:    defaultMaxDelay,
+	}
+
+	if cfg.LLM.TimeoutMs > 0 {
+		t.client.Timeout = time.Duration(cfg.LLM.TimeoutMs) * time.Millisecond
+	}
+	if cfg.LLM.Retry.MaxAttempts > 0 {
+		t.maxAttempts = cfg.LLM.Retry.MaxAttempts
+	}
+	if cfg.LLM.Retry.BaseDelayMs > 0 {
+		t.baseDelay = time.Duration(cfg.LLM.Retry.BaseDelayMs) * time.Millisecond
+	}
+	if cfg.LLM.Retry.MaxDelayMs > 0 {
+		t.maxDelay = time.Duration(cfg.LLM.Retry.MaxDelayMs) * time.Millisecond
+	}
+
+	return t
+}
+
+// postJSON sends body to url and decodes a 200 reply into out. Rate limits,
+// server errors and network failures are retried with exponential backoff
+// and jitter. A Retry-After from the server is waited out in full; if it is
+// longer than maxDelay the call gives up instead of retrying early. It
+// returns the number of attempts made, and on failure an *Error carrying
+// the same count.
+func (t *transport) postJSON(ctx context.Context, url string, headers map[string]string, body, out interface{}) (int, error) {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("marshal request: %w", err)
+	}
+
+	var lastErr *Error
+	for attempt := 1; attempt <= t.maxAttempts; attempt++ {
+		retryAfter, err := t.once(ctx, url, headers, bodyBytes, out)
+		if err == nil {
+			return attempt, nil
+		}
+
+		err.Attempts = attempt
+		lastErr = err
+		if !err.Retryable() || attempt == t.maxAttempts {
+			break
+		}
+		if retryAfter > t.maxDelay {
+			err.Err = fmt.Errorf("%w (Retry-After %s больше допустимого ожидания %s)", err.Err, retryAfter, t.maxDelay)
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, &Error{Kind: KindCanceled, Provider: t.provider, Attempts: attempt, Err: ctx.Err()}
+		case <-time.After(t.backoff(attempt, retryAfter)):
+		}
+	}
+
+	return lastErr.Attempts, lastErr
+}
+
+func (t *transport) once(ctx context.Context, url string, headers map[string]string, body []byte, out interface{}) (time.Duration, *Error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, &Error{Kind: KindBadRequest, Provider: t.provider, Err: err}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, &Error{Kind: KindCanceled, Provider: t.provider, Err: ctx.Err()}
+		}
+		return 0, &Error{Kind: KindNetwork, Provider: t.provider, Err: fmt.Errorf("http request: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return parseRetryAfter(resp.Header.Get("Retry-After")), &Error{
+			Kind:     classifyStatus(resp.StatusCode),
+			Provider: t.provider,
+			Status:   resp.StatusCode,
+			Err:      fmt.Errorf("%s", truncate(string(respBody), 500)),
+		}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, &Error{Kind: KindServer, Provider: t.provider, Status: resp.StatusCode, Err: fmt.Errorf("decode response: %w", err)}
+	}
+
+	return 0, nil
+}
+
+func (t *transport) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	delay := t.baseDelay << (attempt - 1)
+	if delay > t.maxDelay || delay <= 0 {
+		delay = t.maxDelay
+	}
+
+	// Full jitter keeps parallel runs from retrying in lockstep.
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+// parseRetryAfter accepts both delta-seconds and HTTP-date forms.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,177 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testTransport(client *http.Client, maxDelay time.Duration) *transport {
+	return &transport{
+		provider:    "test",
+		client:      client,
+		maxAttempts: 3,
+		baseDelay:   time.Millisecond,
+		maxDelay:    maxDelay,
+	}
+}
+
+// replies serves the given status codes in order, then 200 with {"ok": true}.
+func replies(t *testing.T, header http.Header, codes ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if n <= len(codes) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(codes[n-1])
+			w.Write([]byte(`{"error": "try later"}`))
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestPostJSONRetriesServerErrors(t *testing.T) {
+	srv, calls := replies(t, nil, http.StatusBadGateway, http.StatusServiceUnavailable)
+	tr := testTransport(srv.Client(), 50*time.Millisecond)
+
+	var out struct{ OK bool }
+	attempts, err := tr.postJSON(context.Background(), srv.URL, nil, map[string]string{}, &out)
+	if err != nil {
+		t.Fatalf("postJSON() = %v", err)
+	}
+	if attempts != 3 || *calls != 3 || !out.OK {
+		t.Errorf("attempts = %d, calls = %d, ok = %v; want 3, 3, true", attempts, *calls, out.OK)
+	}
+}
+
+func TestPostJSONGivesUpAfterMaxAttempts(t *testing.T) {
+	srv, calls := replies(t, nil, 500, 500, 500, 500)
+	tr := testTransport(srv.Client(), 50*time.Millisecond)
+
+	attempts, err := tr.postJSON(context.Background(), srv.URL, nil, map[string]string{}, &struct{}{})
+	if KindOf(err) != KindServer {
+		t.Fatalf("postJSON() = %v, want a %s error", err, KindServer)
+	}
+	if attempts != 3 || *calls != 3 || err.(*Error).Attempts != 3 {
+		t.Errorf("attempts = %d, calls = %d, want 3 and 3", attempts, *calls)
+	}
+}
+
+func TestPostJSONDoesNotRetryBadRequest(t *testing.T) {
+	srv, calls := replies(t, nil, http.StatusBadRequest)
+	tr := testTransport(srv.Client(), 50*time.Millisecond)
+
+	_, err := tr.postJSON(context.Background(), srv.URL, nil, map[string]string{}, &struct{}{})
+	if KindOf(err) != KindBadRequest {
+		t.Fatalf("postJSON() = %v, want a %s error", err, KindBadRequest)
+	}
+	if *calls != 1 {
+		t.Errorf("calls = %d, want 1", *calls)
+	}
+	if e := err.(*Error); e.Status != http.StatusBadRequest || !strings.Contains(e.Error(), "try later") {
+		t.Errorf("error = %v, want status 400 and the response body", e)
+	}
+}
+
+func TestPostJSONWaitsFullRetryAfter(t *testing.T) {
+	srv, calls := replies(t, http.Header{"Retry-After": {"1"}}, http.StatusTooManyRequests)
+	// maxDelay is only a cap for our own backoff; the server's wait wins.
+	tr := testTransport(srv.Client(), 2*time.Second)
+
+	started := time.Now()
+	attempts, err := tr.postJSON(context.Background(), srv.URL, nil, map[string]string{}, &struct{}{})
+	if err != nil {
+		t.Fatalf("postJSON() = %v", err)
+	}
+	if took := time.Since(started); took < time.Second {
+		t.Errorf("retried after %s, before Retry-After elapsed", took)
+	}
+	if attempts != 2 || *calls != 2 {
+		t.Errorf("attempts = %d, calls = %d, want 2 and 2", attempts, *calls)
+	}
+}
+
+func TestPostJSONGivesUpOnLongRetryAfter(t *testing.T) {
+	srv, calls := replies(t, http.Header{"Retry-After": {"120"}}, http.StatusTooManyRequests)
+	tr := testTransport(srv.Client(), time.Second)
+
+	started := time.Now()
+	_, err := tr.postJSON(context.Background(), srv.URL, nil, map[string]string{}, &struct{}{})
+	if KindOf(err) != KindRateLimit {
+		t.Fatalf("postJSON() = %v, want a %s error", err, KindRateLimit)
+	}
+	if *calls != 1 || time.Since(started) > 500*time.Millisecond {
+		t.Errorf("calls = %d after %s, want one call and no wait", *calls, time.Since(started))
+	}
+	if !strings.Contains(err.Error(), "Retry-After 2m0s") {
+		t.Errorf("error %q does not mention the Retry-After", err)
+	}
+}
+
+func TestPostJSONStopsOnCancel(t *testing.T) {
+	srv, _ := replies(t, nil, 500, 500, 500)
+	tr := testTransport(srv.Client(), time.Minute)
+	tr.baseDelay = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := tr.postJSON(ctx, srv.URL, nil, map[string]string{}, &struct{}{})
+	if KindOf(err) != KindCanceled {
+		t.Fatalf("postJSON() = %v, want a %s error", err, KindCanceled)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	future := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"5", 5 * time.Second, 5 * time.Second},
+		{"0", 0, 0},
+		{"-3", 0, 0},
+		{"soon", 0, 0},
+		{future, 28 * time.Second, 30 * time.Second},
+		{past, 0, 0},
+	}
+
+	for _, tc := range tests {
+		got := parseRetryAfter(tc.value)
+		if got < tc.min || got > tc.max {
+			t.Errorf("parseRetryAfter(%q) = %s, want between %s and %s", tc.value, got, tc.min, tc.max)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tr := &transport{baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		limit := tr.baseDelay << (attempt - 1)
+		if limit > tr.maxDelay {
+			limit = tr.maxDelay
+		}
+		for i := 0; i < 20; i++ {
+			if d := tr.backoff(attempt, 0); d <= 0 || d > limit {
+				t.Fatalf("backoff(%d) = %s, want in (0, %s]", attempt, d, limit)
+			}
+		}
+	}
+
+	if d := tr.backoff(1, 30*time.Second); d != 30*time.Second {
+		t.Errorf("backoff with Retry-After 30s = %s, want 30s", d)
+	}
+}
@@ -1,9 +1,58 @@
 package llm
 
-import "ai-browser-agent/internal/core"
+import (
+	"context"
+
+	"ai-browser-agent/internal/core"
+)
 
 type Client interface {
-	NextAction(prompt string) (*core.Action, error)
+	NextAction(ctx context.Context, prompt string) (*Response, error)
+}
+
+// Response is the decoded model reply plus how much work it took to get it.
+type Response struct {
+	Action *core.Action
+	// Provider and Model identify which backend produced the action.
+	Provider string
+	Model    string
+	// Attempts counts HTTP requests sent, including retries and re-asks.
+	Attempts int
+	// Reasks counts how many times the model was asked to fix its reply.
+	Reasks int
+	// Usage sums tokens over every request Model made for this action.
+	Usage Usage
+	// Failed holds the tokens spent by models a Chain failed over from.
+	Failed []ModelUsage
+	// Failovers counts clients skipped by a Chain before this one answered.
+	Failovers int
+}
+
+// Spent lists the tokens every model spent on this response.
+func (r *Response) Spent() []ModelUsage {
+	return append(append([]ModelUsage(nil), r.Failed...), ModelUsage{Model: r.Model, Usage: r.Usage})
+}
+
+// ModelUsage is the tokens one model spent, kept apart so that each model
+// is priced at its own rate.
+type ModelUsage struct {
+	Model string `json:"model"`
+	Usage Usage  `json:"usage"`
+}
+
+// Usage is the token count reported by the provider.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+func (u *Usage) Add(o Usage) {
+	u.PromptTokens += o.PromptTokens
+	u.CompletionTokens += o.CompletionTokens
 }
 
 type DummyClient struct{}
@@ -12,9 +61,12 @@ func NewDummy() Client {
 	return &DummyClient{}
 }
 
-func (d *DummyClient) NextAction(prompt string) (*core.Action, error) {
-	return &core.Action{
-		Type:   core.ActionClick,
-		Target: 0,
+func (d *DummyClient) NextAction(ctx context.Context, prompt string) (*Response, error) {
+	return &Response{
+		Provider: "dummy",
+		Action: &core.Action{
+			Type:   core.ActionClick,
+			Target: 0,
+		},
 	}, nil
 }
@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"ai-browser-agent/internal/agent/promts"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"ai-browser-agent/internal/config"
+	"ai-browser-agent/internal/core"
+)
+
+// OllamaClient talks to a local Ollama server via /api/chat. No API key is
+// needed; structured output is constrained by the action JSON schema.
+type OllamaClient struct {
+	baseURL      string
+	model        string
+	maxTokens    int
+	temperature  float32
+	mode         string
+	parseRetries int
+	http         *transport
+}
+
+func NewOllama(cfg *config.Config) Client {
+	mode := cfg.LLM.Mode
+	if mode == "" {
+		mode = ModeJSON
+	}
+
+	return &OllamaClient{
+		baseURL:      strings.TrimRight(cfg.Env.OllamaBaseURL, "/"),
+		model:        cfg.LLM.Model,
+		maxTokens:    cfg.LLM.MaxTokens,
+		temperature:  cfg.LLM.Temperature,
+		mode:         mode,
+		parseRetries: parseRetries(cfg),
+		http:         newTransport("ollama", cfg),
+	}
+}
+
+type ollamaResponse struct {
+	Message struct {
+		Content   string `json:"content"`
+		ToolCalls []struct {
+			Function struct {
+				Name      string          `json:"name"`
+				Arguments json.RawMessage `json:"arguments"`
+			} `json:"function"`
+		} `json:"tool_calls"`
+	} `json:"message"`
+	Done            bool `json:"done"`
+	PromptEvalCount int  `json:"prompt_eval_count"`
+	EvalCount       int  `json:"eval_count"`
+}
+
+func (o *OllamaClient) NextAction(ctx context.Context, fullPrompt string) (*Response, error) {
+	return reask(ctx, o.http.provider, o.model, o.parseRetries, fullPrompt, o.ask)
+}
+
+func (o *OllamaClient) ask(ctx context.Context, fullPrompt string, resp *Response) (*core.Action, error) {
+	reqBody := map[string]interface{}{
+		"model": o.model,
+		"messages": []map[string]string{
+			{
+				"role":    "system",
+				"content": promts.System(o.mode == ModeTools),
+			},
+			{
+				"role":    "user",
+				"content": fullPrompt,
+			},
+		},
+		"stream": false,
+		"options": map[string]interface{}{
+			"temperature": o.temperature,
+			"num_predict": o.maxTokens,
+		},
+	}
+
+	if o.mode == ModeTools {
+		reqBody["tools"] = openAITools()
+	} else {
+		reqBody["format"] = actionSchema()
+	}
+
+	var apiResp ollamaResponse
+	attempts, err := o.http.postJSON(ctx, o.baseURL+"/api/chat", nil, reqBody, &apiResp)
+	resp.Attempts += attempts
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Usage.Add(Usage{
+		PromptTokens:     apiResp.PromptEvalCount,
+		CompletionTokens: apiResp.EvalCount,
+	})
+
+	if len(apiResp.Message.ToolCalls) > 0 {
+		actions := make([]*core.Action, 0, len(apiResp.Message.ToolCalls))
+		for _, c := range apiResp.Message.ToolCalls {
+			action, err := actionFromCall(c.Function.Name, c.Function.Arguments)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, action)
+		}
+		return batchOf(actions)
+	}
+
+	action, err := parseAction(apiResp.Message.Content)
+	return action, err
+}
@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"ai-browser-agent/internal/config"
+	"ai-browser-agent/internal/core"
+)
+
+func newOllamaStub(t *testing.T, mode string, replies ...string) (*apiStub, Client) {
+	t.Helper()
+	stub := &apiStub{t: t, replies: replies}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		LLM: config.LLMConfig{Model: "qwen-test", MaxTokens: 256, Mode: mode, ParseRetries: 1},
+		Env: config.EnvConfig{OllamaBaseURL: srv.URL + "/"},
+	}
+	return stub, NewOllama(cfg)
+}
+
+func TestOllamaFormatSchema(t *testing.T) {
+	stub, client := newOllamaStub(t, ModeJSON, `{
+		"message": {"role": "assistant", "content": "{\"type\": \"type\", \"target\": 2, \"text\": \"айфон\"}"},
+		"done": true,
+		"prompt_eval_count": 300,
+		"eval_count": 20
+	}`)
+
+	resp, err := client.NextAction(context.Background(), "GOAL: найти")
+	if err != nil {
+		t.Fatalf("NextAction() = %v", err)
+	}
+
+	want := &core.Action{Type: core.ActionTypeText, Target: 2, Text: "айфон"}
+	if !reflect.DeepEqual(resp.Action, want) {
+		t.Errorf("action = %+v, want %+v", resp.Action, want)
+	}
+	if resp.Provider != "ollama" || resp.Model != "qwen-test" || resp.Usage != (Usage{PromptTokens: 300, CompletionTokens: 20}) {
+		t.Errorf("response = %+v", resp)
+	}
+
+	if got := stub.paths[0]; got != "/api/chat" {
+		t.Errorf("path = %q, want /api/chat", got)
+	}
+	if h := stub.headers[0].Get("Authorization"); h != "" {
+		t.Errorf("unexpected Authorization header %q", h)
+	}
+
+	req := stub.requests[0]
+	if req["stream"] != false {
+		t.Errorf("stream = %v, want false", req["stream"])
+	}
+	format, _ := req["format"].(map[string]interface{})
+	if format["type"] != "object" {
+		t.Errorf("format = %v, want the action schema", req["format"])
+	}
+	props, _ := format["properties"].(map[string]interface{})
+	for _, field := range []string{"type", "target", "text", "actions"} {
+		if _, ok := props[field]; !ok {
+			t.Errorf("format schema has no %q property", field)
+		}
+	}
+	if _, ok := req["tools"]; ok {
+		t.Error("JSON mode request declares tools")
+	}
+	opts, _ := req["options"].(map[string]interface{})
+	if opts["num_predict"] != float64(256) {
+		t.Errorf("options = %v", req["options"])
+	}
+}
+
+func TestOllamaToolCalls(t *testing.T) {
+	stub, client := newOllamaStub(t, ModeTools, `{
+		"message": {
+			"role": "assistant",
+			"content": "",
+			"tool_calls": [{"function": {"name": "select_option", "arguments": {"target": 5, "option": "По цене"}}}]
+		},
+		"done": true,
+		"prompt_eval_count": 400,
+		"eval_count": 12
+	}`)
+
+	resp, err := client.NextAction(context.Background(), "GOAL: отсортировать")
+	if err != nil {
+		t.Fatalf("NextAction() = %v", err)
+	}
+
+	want := &core.Action{Type: core.ActionSelect, Target: 5, Option: "По цене"}
+	if !reflect.DeepEqual(resp.Action, want) {
+		t.Errorf("action = %+v, want %+v", resp.Action, want)
+	}
+
+	req := stub.requests[0]
+	if tools, _ := req["tools"].([]interface{}); len(tools) != len(actionTools) {
+		t.Errorf("request declares %d tools, want %d", len(tools), len(actionTools))
+	}
+	if _, ok := req["format"]; ok {
+		t.Error("tools mode request constrains format")
+	}
+}
+
+func TestOllamaToolCallsBatch(t *testing.T) {
+	_, client := newOllamaStub(t, ModeTools, `{
+		"message": {
+			"role": "assistant",
+			"content": "",
+			"tool_calls": [
+				{"function": {"name": "type", "arguments": {"target": 1, "text": "айфон 17"}}},
+				{"function": {"name": "press_key", "arguments": "{\"key\": \"Enter\"}"}}
+			]
+		},
+		"done": true
+	}`)
+
+	resp, err := client.NextAction(context.Background(), "GOAL: найти")
+	if err != nil {
+		t.Fatalf("NextAction() = %v", err)
+	}
+
+	want := &core.Action{Type: core.ActionBatch, Target: core.NoTarget, Actions: []core.Action{
+		{Type: core.ActionTypeText, Target: 1, Text: "айфон 17"},
+		{Type: core.ActionPressKey, Target: core.NoTarget, Key: "Enter"},
+	}}
+	if !reflect.DeepEqual(resp.Action, want) {
+		t.Errorf("action = %+v, want %+v", resp.Action, want)
+	}
+}
+
+func TestOllamaReasksUnparsableContent(t *testing.T) {
+	stub, client := newOllamaStub(t, ModeJSON,
+		`{"message": {"content": "Сейчас нажму кнопку"}, "done": true, "prompt_eval_count": 100, "eval_count": 5}`,
+		`{"message": {"content": "{\"type\": \"click\", \"target\": 0}"}, "done": true, "prompt_eval_count": 120, "eval_count": 6}`,
+	)
+
+	resp, err := client.NextAction(context.Background(), "GOAL: найти")
+	if err != nil {
+		t.Fatalf("NextAction() = %v", err)
+	}
+	if resp.Action.Type != core.ActionClick || resp.Reasks != 1 || resp.Attempts != 2 {
+		t.Errorf("response = %+v, want a click after one re-ask", resp)
+	}
+	if resp.Usage != (Usage{PromptTokens: 220, CompletionTokens: 11}) {
+		t.Errorf("usage = %+v, want both requests summed", resp.Usage)
+	}
+	if len(stub.requests) != 2 {
+		t.Errorf("requests = %d, want 2", len(stub.requests))
+	}
+}
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"ai-browser-agent/internal/core"
+)
+
+// parseAction decodes the first JSON object found in a model reply.
+// Models regularly wrap the object in prose or markdown fences, or emit
+// several objects in a row; only the first one is used.
+func parseAction(content string) (*core.Action, error) {
+	if actions, ok := parseActionList(content); ok {
+		return batchOf(actions)
+	}
+
+	raw := firstJSONObject(content)
+	if raw == "" {
+		return nil, parseError(fmt.Errorf("no JSON object in model reply: %q", truncate(content, 200)))
+	}
+
+	var action core.Action
+	if err := json.Unmarshal([]byte(raw), &action); err != nil {
+		return nil, parseError(fmt.Errorf("unmarshal action JSON (%s): %w", raw, err))
+	}
+
+	if action.Type == "" {
+		return nil, parseError(fmt.Errorf("empty action type"))
+	}
+
+	return &action, nil
+}
+
+// parseActionList accepts a reply that is a bare JSON array of actions,
+// which some models produce instead of a batch object.
+func parseActionList(content string) ([]*core.Action, bool) {
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.Trim(content, "` \n")
+	if !strings.HasPrefix(content, "[") {
+		return nil, false
+	}
+
+	var actions []*core.Action
+	if err := json.Unmarshal([]byte(content), &actions); err != nil || len(actions) == 0 {
+		return nil, false
+	}
+	for _, a := range actions {
+		if a == nil || a.Type == "" {
+			return nil, false
+		}
+	}
+	return actions, true
+}
+
+// firstJSONObject returns the first balanced {...} block in s, honoring
+// string literals so braces inside text values do not confuse the scan.
+func firstJSONObject(s string) string {
+	start := strings.IndexByte(s, '{')
+	if start < 0 {
+		return ""
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+
+	for i := start; i < len(s); i++ {
+		c := s[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return s[start : i+1]
+			}
+		}
+	}
+
+	return ""
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
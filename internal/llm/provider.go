@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-browser-agent/internal/config"
+)
+
+// New builds the client described by cfg.LLM: the primary provider, its
+// fallbacks as a Chain, and an optional Router to a stronger model, wrapped
+// in a cassette recorder or replaced by a replayer when configured.
+func New(cfg *config.Config) (Client, error) {
+	switch cfg.LLM.Cassette.Mode {
+	case "":
+		return newLive(cfg)
+	case CassetteReplay:
+		return NewReplayer(cfg.LLM.Cassette.Path)
+	case CassetteRecord:
+		client, err := newLive(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewRecorder(client, cfg.LLM.Cassette.Path)
+	default:
+		return nil, fmt.Errorf("unknown cassette mode: %q", cfg.LLM.Cassette.Mode)
+	}
+}
+
+func newLive(cfg *config.Config) (Client, error) {
+	primary, err := newProvider(cfg, config.ModelRef{Provider: cfg.LLM.Provider, Model: cfg.LLM.Model})
+	if err != nil {
+		return nil, err
+	}
+
+	clients := []Client{primary}
+	for _, ref := range cfg.LLM.Fallbacks {
+		c, err := newProvider(cfg, ref)
+		if err != nil {
+			return nil, fmt.Errorf("fallback: %w", err)
+		}
+		clients = append(clients, c)
+	}
+	client := NewChain(clients...)
+
+	strong := cfg.LLM.Routing.Strong
+	if strong.Provider == "" && strong.Model == "" {
+		return client, nil
+	}
+
+	// The strong model still falls back along the same chain.
+	strongClient, err := newProvider(cfg, strong)
+	if err != nil {
+		return nil, fmt.Errorf("routing: %w", err)
+	}
+
+	return NewRouter(client, NewChain(append([]Client{strongClient}, clients...)...), cfg.LLM.Routing.EscalateAfterErrors), nil
+}
+
+// newProvider builds a single client for ref. Empty fields inherit the
+// primary provider and model. Unknown OpenAI-compatible vendors go through
+// ZaiClient.
+func newProvider(cfg *config.Config, ref config.ModelRef) (Client, error) {
+	c := *cfg
+	if ref.Provider != "" {
+		c.LLM.Provider = ref.Provider
+	}
+	if ref.Model != "" {
+		c.LLM.Model = ref.Model
+	}
+
+	switch strings.ToLower(c.LLM.Provider) {
+	case "anthropic", "claude":
+		return NewAnthropic(&c), nil
+	case "", "zai", "openai", "mistral", "groq", "together":
+		return NewZai(&c), nil
+	case "ollama":
+		return NewOllama(&c), nil
+	case "llamacpp", "llama.cpp":
+		return NewLlamaCpp(&c), nil
+	case "dummy":
+		return NewDummy(), nil
+	default:
+		return nil, fmt.Errorf("unknown llm provider: %q", c.LLM.Provider)
+	}
+}
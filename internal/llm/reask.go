@@ -0,0 +1,48 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ai-browser-agent/internal/core"
+)
+
+// askFunc performs a single model call, adding the HTTP attempts and tokens
+// it spent to resp.
+type askFunc func(ctx context.Context, prompt string, resp *Response) (*core.Action, error)
+
+// reask calls ask and, when the reply cannot be decoded into an action,
+// asks again with the parse error appended to the prompt. Transport errors
+// are returned as is; the final error always carries the total attempts.
+func reask(ctx context.Context, provider, model string, maxReasks int, prompt string, ask askFunc) (*Response, error) {
+	resp := &Response{Provider: provider, Model: model}
+	current := prompt
+
+	for {
+		action, err := ask(ctx, current, resp)
+		if err == nil {
+			resp.Action = action
+			return resp, nil
+		}
+
+		var e *Error
+		if !errors.As(err, &e) {
+			// Cancellation and local failures are not provider errors.
+			return nil, err
+		}
+		e.Provider, e.Model = provider, model
+		e.Attempts = resp.Attempts
+		e.Usage = resp.Usage
+
+		if e.Kind != KindParse || resp.Reasks >= maxReasks {
+			return nil, e
+		}
+
+		resp.Reasks++
+		current = fmt.Sprintf(
+			"%s\n\nТВОЙ ПРЕДЫДУЩИЙ ОТВЕТ НЕ УДАЛОСЬ РАЗОБРАТЬ: %v\nОтветь снова ровно одним корректным действием.",
+			prompt, e.Err,
+		)
+	}
+}
@@ -0,0 +1,354 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"ai-browser-agent/internal/core"
+)
+
+const (
+	// ModeJSON asks the model for a bare JSON object in the message content.
+	ModeJSON = "json"
+	// ModeTools declares every action as a function and reads tool calls back.
+	ModeTools = "tools"
+)
+
+// toolSpec describes one core.ActionType as a callable function.
+type toolSpec struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+func objectSchema(props map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+var (
+	reasonProp = map[string]interface{}{
+		"type":        "string",
+		"description": "Short explanation of why this action moves towards the goal",
+	}
+	targetProp = map[string]interface{}{
+		"type":        "integer",
+		"description": "0-based index of the element in the current SNAPSHOT",
+	}
+	tabProp = map[string]interface{}{
+		"type":        "integer",
+		"description": "Tab index from TABS",
+	}
+)
+
+var actionTools = []toolSpec{
+	{
+		Name:        string(core.ActionClick),
+		Description: "Click the element with the given snapshot index.",
+		Parameters: objectSchema(map[string]interface{}{
+			"target": targetProp,
+			"reason": reasonProp,
+		}, "target"),
+	},
+	{
+		Name:        string(core.ActionTypeText),
+		Description: "Replace the value of an input field with the given text.",
+		Parameters: objectSchema(map[string]interface{}{
+			"target": targetProp,
+			"text":   map[string]interface{}{"type": "string", "description": "Text to type"},
+			"reason": reasonProp,
+		}, "target", "text"),
+	},
+	{
+		Name:        string(core.ActionNavigate),
+		Description: "Open the given absolute URL in the current tab.",
+		Parameters: objectSchema(map[string]interface{}{
+			"url":    map[string]interface{}{"type": "string", "description": "Full URL including scheme"},
+			"reason": reasonProp,
+		}, "url"),
+	},
+	{
+		Name:        string(core.ActionPressKey),
+		Description: "Press a key or chord, e.g. Enter, Escape, ArrowDown, Control+A, on the target element or wherever the focus is.",
+		Parameters: objectSchema(map[string]interface{}{
+			"key":    map[string]interface{}{"type": "string", "description": "Playwright key name or chord joined with + (Shift, Control, Alt, Meta, ControlOrMeta)"},
+			"target": map[string]interface{}{"type": "integer", "description": "Element to focus first; omit to use the current focus"},
+			"reason": reasonProp,
+		}, "key"),
+	},
+	{
+		Name:        string(core.ActionDrag),
+		Description: "Drag the target element and drop it onto another element (sliders, kanban boards, sortable lists).",
+		Parameters: objectSchema(map[string]interface{}{
+			"target": targetProp,
+			"to":     map[string]interface{}{"type": "integer", "description": "Index of the element to drop onto"},
+			"reason": reasonProp,
+		}, "target", "to"),
+	},
+	{
+		Name:        string(core.ActionScroll),
+		Description: "Scroll the page, or the scrollable container given by target, to reveal more content.",
+		Parameters: objectSchema(map[string]interface{}{
+			"direction": map[string]interface{}{
+				"type": "string",
+				"enum": []string{core.ScrollDown, core.ScrollUp, core.ScrollTop, core.ScrollBottom},
+			},
+			"amount": map[string]interface{}{"type": "integer", "description": "Pixels to scroll; omit for one screen"},
+			"target": map[string]interface{}{"type": "integer", "description": "Index of a scrollable container or of any element inside it; omit to scroll the page"},
+			"reason": reasonProp,
+		}, "direction"),
+	},
+	{
+		Name:        string(core.ActionSelect),
+		Description: "Choose an option of a native <select> by its value or visible label.",
+		Parameters: objectSchema(map[string]interface{}{
+			"target": targetProp,
+			"option": map[string]interface{}{"type": "string", "description": "Option value or label as listed in the snapshot"},
+			"reason": reasonProp,
+		}, "target", "option"),
+	},
+	{
+		Name:        string(core.ActionCheck),
+		Description: "Tick a checkbox, radio button or switch.",
+		Parameters: objectSchema(map[string]interface{}{
+			"target": targetProp,
+			"reason": reasonProp,
+		}, "target"),
+	},
+	{
+		Name:        string(core.ActionUncheck),
+		Description: "Clear a checkbox or switch.",
+		Parameters: objectSchema(map[string]interface{}{
+			"target": targetProp,
+			"reason": reasonProp,
+		}, "target"),
+	},
+	{
+		Name:        string(core.ActionHover),
+		Description: "Move the mouse over an element, e.g. to open a hover menu.",
+		Parameters: objectSchema(map[string]interface{}{
+			"target": targetProp,
+			"reason": reasonProp,
+		}, "target"),
+	},
+	{
+		Name:        string(core.ActionBack),
+		Description: "Go back in the history of the active tab.",
+		Parameters:  objectSchema(map[string]interface{}{"reason": reasonProp}),
+	},
+	{
+		Name:        string(core.ActionForward),
+		Description: "Go forward in the history of the active tab.",
+		Parameters:  objectSchema(map[string]interface{}{"reason": reasonProp}),
+	},
+	{
+		Name:        string(core.ActionReload),
+		Description: "Reload the active tab.",
+		Parameters:  objectSchema(map[string]interface{}{"reason": reasonProp}),
+	},
+	{
+		Name:        string(core.ActionSwitch),
+		Description: "Make another open tab active.",
+		Parameters: objectSchema(map[string]interface{}{
+			"tab":    tabProp,
+			"reason": reasonProp,
+		}, "tab"),
+	},
+	{
+		Name:        string(core.ActionCloseTab),
+		Description: "Close a tab; without tab closes the active one.",
+		Parameters: objectSchema(map[string]interface{}{
+			"tab":    tabProp,
+			"reason": reasonProp,
+		}),
+	},
+	{
+		Name:        string(core.ActionOpenTab),
+		Description: "Open a URL in a new tab and make it active.",
+		Parameters: objectSchema(map[string]interface{}{
+			"url":    map[string]interface{}{"type": "string", "description": "Full URL including scheme"},
+			"reason": reasonProp,
+		}, "url"),
+	},
+	{
+		Name:        string(core.ActionUpload),
+		Description: "Attach files from the user's uploads folder to a file input or upload button.",
+		Parameters: objectSchema(map[string]interface{}{
+			"target": targetProp,
+			"files": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "File names inside the uploads folder",
+			},
+			"reason": reasonProp,
+		}, "target", "files"),
+	},
+	{
+		Name:        string(core.ActionExtract),
+		Description: "Read the text of an element (or of the whole page without target) to find values for the answer.",
+		Parameters: objectSchema(map[string]interface{}{
+			"target": map[string]interface{}{"type": "integer", "description": "Element index; omit to read the page"},
+			"query":  map[string]interface{}{"type": "string", "description": "What you are looking for"},
+			"reason": reasonProp,
+		}, "query"),
+	},
+	{
+		Name:        string(core.ActionElements),
+		Description: "Change which interactive elements the next SNAPSHOT lists when some are not shown: another page of the ranked list, or only elements whose text contains query.",
+		Parameters: objectSchema(map[string]interface{}{
+			"page":   map[string]interface{}{"type": "integer", "description": "1-based page of the list; default 1"},
+			"query":  map[string]interface{}{"type": "string", "description": "Words the element's name, value or options must contain; omit to show all"},
+			"reason": reasonProp,
+		}),
+	},
+	{
+		Name:        string(core.ActionWait),
+		Description: "Wait until text appears (inside target, if given), until an element with the given role (and text as its name) appears, until target becomes visible, or for a number of seconds. Use for content that loads slowly.",
+		Parameters: objectSchema(map[string]interface{}{
+			"text":    map[string]interface{}{"type": "string", "description": "Text to wait for; with role, the element's name"},
+			"role":    map[string]interface{}{"type": "string", "description": "ARIA role of an element that is not in the snapshot yet (button, link, dialog, row...)"},
+			"target":  map[string]interface{}{"type": "integer", "description": "Element index to wait for, or to search the text or role in"},
+			"seconds": map[string]interface{}{"type": "number", "description": "Seconds to wait; also the timeout for text and target"},
+			"reason":  reasonProp,
+		}),
+	},
+	{
+		Name:        string(core.ActionDialog),
+		Description: "Answer the JavaScript dialog (alert, confirm, prompt) that is blocking the page.",
+		Parameters: objectSchema(map[string]interface{}{
+			"accept": map[string]interface{}{"type": "boolean", "description": "true to accept (OK), false to dismiss (Cancel)"},
+			"text":   map[string]interface{}{"type": "string", "description": "Text to enter into a prompt dialog"},
+			"reason": reasonProp,
+		}, "accept"),
+	},
+	{
+		Name:        string(core.ActionBatch),
+		Description: "Run several actions in order in one step, for obvious sequences like type then Enter. The batch stops early if the page navigates or the snapshot changes.",
+		Parameters: objectSchema(map[string]interface{}{
+			"actions": map[string]interface{}{
+				"type":        "array",
+				"description": "Actions to run in order; each is an object with \"type\" (a tool name other than batch, extract, elements and done) and that tool's arguments",
+				"items": objectSchema(map[string]interface{}{
+					"type": map[string]interface{}{"type": "string"},
+				}, "type"),
+			},
+			"reason": reasonProp,
+		}, "actions"),
+	},
+	{
+		Name:        string(core.ActionDone),
+		Description: "Finish the run when the goal has been achieved and report the result.",
+		Parameters: objectSchema(map[string]interface{}{
+			"answer": map[string]interface{}{"type": "string", "description": "Answer to the user's goal in plain text"},
+			"data":   map[string]interface{}{"type": "object", "description": "Structured result matching RESULT SCHEMA, if one is given"},
+			"reason": reasonProp,
+		}),
+	},
+}
+
+// openAITools renders actionTools in the chat-completions "tools" format.
+func openAITools() []map[string]interface{} {
+	tools := make([]map[string]interface{}, 0, len(actionTools))
+	for _, t := range actionTools {
+		tools = append(tools, map[string]interface{}{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        t.Name,
+				"description": t.Description,
+				"parameters":  t.Parameters,
+			},
+		})
+	}
+	return tools
+}
+
+// actionFromCall builds an action from a function name and its JSON arguments.
+func actionFromCall(name string, args json.RawMessage) (*core.Action, error) {
+	action := core.Action{Target: core.NoTarget}
+	if len(args) > 0 && string(args) != "null" {
+		// Some providers send arguments as a JSON-encoded string.
+		var encoded string
+		if err := json.Unmarshal(args, &encoded); err == nil {
+			args = json.RawMessage(encoded)
+		}
+		if string(args) != "" {
+			if err := json.Unmarshal(args, &action); err != nil {
+				return nil, parseError(fmt.Errorf("unmarshal tool arguments for %s (%s): %w", name, string(args), err))
+			}
+		}
+	}
+
+	action.Type = core.ActionType(name)
+	if !isKnownTool(name) {
+		return nil, parseError(fmt.Errorf("unknown tool called: %s", name))
+	}
+
+	return &action, nil
+}
+
+// standalone actions are not run by the executor's batch, so the model
+// has to send them as the only action of a reply.
+var standalone = map[core.ActionType]bool{
+	core.ActionBatch:    true,
+	core.ActionExtract:  true,
+	core.ActionElements: true,
+	core.ActionDone:     true,
+}
+
+// batchOf returns the only action, or wraps several tool calls from one
+// reply into a batch. A standalone action among several is a parse error,
+// so the model is asked again.
+func batchOf(actions []*core.Action) (*core.Action, error) {
+	if len(actions) == 1 {
+		return actions[0], nil
+	}
+	for _, a := range actions {
+		if standalone[a.Type] {
+			return nil, parseError(fmt.Errorf("%s нельзя отправлять вместе с другими действиями: ответь только им или только остальными", a.Type))
+		}
+	}
+
+	batch := &core.Action{Type: core.ActionBatch, Target: core.NoTarget}
+	for _, a := range actions {
+		batch.Actions = append(batch.Actions, *a)
+	}
+	return batch, nil
+}
+
+func isKnownTool(name string) bool {
+	for _, t := range actionTools {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// actionSchema merges all tool parameters into a single JSON schema for a
+// core.Action object, used by providers with schema-constrained output.
+func actionSchema() map[string]interface{} {
+	names := make([]string, 0, len(actionTools))
+	props := map[string]interface{}{}
+
+	for _, t := range actionTools {
+		names = append(names, t.Name)
+		if p, ok := t.Parameters["properties"].(map[string]interface{}); ok {
+			for k, v := range p {
+				props[k] = v
+			}
+		}
+	}
+
+	props["type"] = map[string]interface{}{
+		"type": "string",
+		"enum": names,
+	}
+
+	return objectSchema(props, "type")
+}
@@ -2,41 +2,89 @@ package llm
 
 import (
 	"ai-browser-agent/internal/agent/promts"
-	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
+	"errors"
+	"log"
+	"strings"
 
 	"ai-browser-agent/internal/config"
 	"ai-browser-agent/internal/core"
 )
 
 type ZaiClient struct {
-	apiKey      string
-	baseURL     string
-	model       string
-	maxTokens   int
-	temperature float32
-	client      *http.Client
+	apiKey       string
+	baseURL      string
+	model        string
+	maxTokens    int
+	temperature  float32
+	mode         string
+	parseRetries int
+	http         *transport
 }
 
 func NewZai(cfg *config.Config) Client {
+	return newChatCompletions(cfg, "zai", cfg.Env.ZaiAPIKey, cfg.Env.ZaiBaseURL)
+}
+
+// NewLlamaCpp targets the OpenAI-compatible endpoint of a local llama.cpp server.
+func NewLlamaCpp(cfg *config.Config) Client {
+	return newChatCompletions(cfg, "llamacpp", "", cfg.Env.LlamaCppBaseURL)
+}
+
+func newChatCompletions(cfg *config.Config, provider, apiKey, baseURL string) Client {
+	mode := cfg.LLM.Mode
+	if mode == "" {
+		mode = ModeJSON
+	}
+
 	return &ZaiClient{
-		apiKey:      cfg.Env.ZaiAPIKey,
-		baseURL:     cfg.Env.ZaiBaseURL,
-		model:       cfg.LLM.Model,
-		maxTokens:   cfg.LLM.MaxTokens,
-		temperature: cfg.LLM.Temperature,
-		client:      &http.Client{},
+		apiKey:       apiKey,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		model:        cfg.LLM.Model,
+		maxTokens:    cfg.LLM.MaxTokens,
+		temperature:  cfg.LLM.Temperature,
+		mode:         mode,
+		parseRetries: parseRetries(cfg),
+		http:         newTransport(provider, cfg),
 	}
 }
 
-func (z *ZaiClient) NextAction(fullPrompt string) (*core.Action, error) {
+type chatToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string         `json:"content"`
+			ToolCalls []chatToolCall `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (z *ZaiClient) NextAction(ctx context.Context, fullPrompt string) (*Response, error) {
+	return reask(ctx, z.http.provider, z.model, z.parseRetries, fullPrompt, z.ask)
+}
+
+func (z *ZaiClient) ask(ctx context.Context, fullPrompt string, resp *Response) (*core.Action, error) {
+	return z.askIn(ctx, z.mode, fullPrompt, resp)
+}
+
+// askIn sends one request in the given mode. The client is shared by the
+// cheap and strong chains, so the mode is passed along instead of stored.
+func (z *ZaiClient) askIn(ctx context.Context, mode, fullPrompt string, resp *Response) (*core.Action, error) {
 	messages := []map[string]string{
 		{
 			"role":    "system",
-			"content": promts.SystemPrompt,
+			"content": promts.System(mode == ModeTools),
 		},
 		{
 			"role":    "user",
@@ -49,60 +97,66 @@ func (z *ZaiClient) NextAction(fullPrompt string) (*core.Action, error) {
 		"messages":    messages,
 		"max_tokens":  z.maxTokens,
 		"temperature": z.temperature,
-		"response_format": map[string]string{
-			"type": "json_object",
-		},
 	}
 
-	bodyBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
+	if mode == ModeTools {
+		reqBody["tools"] = openAITools()
+		reqBody["tool_choice"] = "required"
+	} else {
+		reqBody["response_format"] = map[string]string{
+			"type": "json_object",
+		}
 	}
 
-	req, err := http.NewRequest("POST", z.baseURL+"/chat/completions", bytes.NewReader(bodyBytes))
-	if err != nil {
-		return nil, err
+	headers := map[string]string{}
+	if z.apiKey != "" {
+		headers["Authorization"] = "Bearer " + z.apiKey
 	}
 
-	req.Header.Set("Authorization", "Bearer "+z.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+	var apiResp chatResponse
+	attempts, err := z.http.postJSON(ctx, z.baseURL+"/chat/completions", headers, reqBody, &apiResp)
+	resp.Attempts += attempts
 
-	resp, err := z.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("http request: %w", err)
+	var e *Error
+	if mode == ModeTools && errors.As(err, &e) && toolsRejected(e) {
+		// Providers without function calling reject the tools field outright;
+		// ask this one request again as plain JSON content.
+		log.Printf("Провайдер не поддерживает tools, повторяю запрос в JSON-режиме: %v", err)
+		return z.askIn(ctx, ModeJSON, fullPrompt, resp)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ZAI API error %d: %s", resp.StatusCode, string(body))
+	if err != nil {
+		return nil, err
 	}
 
-	var apiResp struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
-	}
+	resp.Usage.Add(Usage{
+		PromptTokens:     apiResp.Usage.PromptTokens,
+		CompletionTokens: apiResp.Usage.CompletionTokens,
+	})
 
 	if len(apiResp.Choices) == 0 {
-		return nil, fmt.Errorf("no choices in response")
+		return nil, &Error{Kind: KindServer, Err: errors.New("no choices in response")}
 	}
 
-	rawJSON := apiResp.Choices[0].Message.Content
-
-	var action core.Action
-	if err = json.Unmarshal([]byte(rawJSON), &action); err != nil {
-		return nil, fmt.Errorf("unmarshal action JSON (%s): %w", rawJSON, err)
+	msg := apiResp.Choices[0].Message
+	if len(msg.ToolCalls) > 0 {
+		actions := make([]*core.Action, 0, len(msg.ToolCalls))
+		for _, c := range msg.ToolCalls {
+			action, err := actionFromCall(c.Function.Name, c.Function.Arguments)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, action)
+		}
+		return batchOf(actions)
 	}
 
-	if action.Type == "" {
-		return nil, fmt.Errorf("empty action type")
-	}
+	action, err := parseAction(msg.Content)
+	return action, err
+}
 
-	return &action, nil
+// toolsRejected reports whether a bad request complains about the tools or
+// tool_choice fields rather than, say, the prompt length.
+func toolsRejected(e *Error) bool {
+	return e.Kind == KindBadRequest && e.Err != nil &&
+		strings.Contains(strings.ToLower(e.Err.Error()), "tool")
 }
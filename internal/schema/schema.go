@@ -0,0 +1,254 @@
+// Package schema validates JSON values against the subset of JSON Schema
+// the agent accepts for structured answers: type, properties, required,
+// additionalProperties (boolean), items and enum. Annotations (title,
+// description, ...) are allowed; any other keyword is rejected by Parse
+// rather than silently ignored.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type Schema struct {
+	Type                 interface{}        `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []interface{}      `json:"enum"`
+}
+
+// keywords are the constraints Validate enforces; annotations are accepted
+// and have no effect on validation.
+var (
+	keywords = map[string]bool{
+		"type": true, "properties": true, "required": true,
+		"additionalProperties": true, "items": true, "enum": true,
+	}
+	annotations = map[string]bool{
+		"$schema": true, "$id": true, "$comment": true, "title": true,
+		"description": true, "default": true, "examples": true,
+	}
+	knownTypes = map[string]bool{
+		"object": true, "array": true, "string": true, "number": true,
+		"integer": true, "boolean": true, "null": true,
+	}
+)
+
+// Parse decodes a schema document, rejecting keywords Validate would not
+// enforce.
+func Parse(raw []byte) (*Schema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	if err := check("$", doc); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	return &s, nil
+}
+
+// check walks a decoded schema and reports the first unsupported keyword
+// or malformed value.
+func check(path string, node interface{}) error {
+	obj, ok := node.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: схема должна быть объектом", path)
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := obj[k]
+		switch {
+		case annotations[k]:
+			continue
+		case !keywords[k]:
+			return fmt.Errorf("%s: ключевое слово %q не поддерживается (доступны: type, properties, required, additionalProperties, items, enum)", path, k)
+		}
+
+		switch k {
+		case "type":
+			types, ok := v.([]interface{})
+			if !ok {
+				types = []interface{}{v}
+			}
+			for _, t := range types {
+				if name, ok := t.(string); !ok || !knownTypes[name] {
+					return fmt.Errorf("%s: неизвестный тип %v", path, t)
+				}
+			}
+		case "properties":
+			props, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: properties должно быть объектом", path)
+			}
+			names := make([]string, 0, len(props))
+			for name := range props {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if err := check(path+".properties."+name, props[name]); err != nil {
+					return err
+				}
+			}
+		case "required":
+			list, ok := v.([]interface{})
+			if !ok {
+				return fmt.Errorf("%s: required должно быть массивом строк", path)
+			}
+			for _, name := range list {
+				if _, ok := name.(string); !ok {
+					return fmt.Errorf("%s: required должно быть массивом строк", path)
+				}
+			}
+		case "additionalProperties":
+			if _, ok := v.(bool); !ok {
+				return fmt.Errorf("%s: additionalProperties поддерживается только как true/false", path)
+			}
+		case "items":
+			if err := check(path+".items", v); err != nil {
+				return err
+			}
+		case "enum":
+			if _, ok := v.([]interface{}); !ok {
+				return fmt.Errorf("%s: enum должно быть массивом", path)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks raw JSON against s and reports every mismatch found.
+func (s *Schema) Validate(raw []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var errs []string
+	s.validate("$", v, &errs)
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, v interface{}, errs *[]string) {
+	if s == nil {
+		return
+	}
+
+	if types := s.types(); len(types) > 0 {
+		ok := false
+		for _, t := range types {
+			if hasType(v, t) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: ожидался тип %s, получено %s", path, strings.Join(types, "|"), typeOf(v)))
+			return
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, fmt.Sprintf("%s: значение %v не входит в enum %v", path, v, s.Enum))
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: нет обязательного поля %q", path, name))
+			}
+		}
+
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				prop.validate(path+"."+k, val[k], errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, fmt.Sprintf("%s: лишнее поле %q", path, k))
+			}
+		}
+
+	case []interface{}:
+		for i, item := range val {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+		}
+	}
+}
+
+func (s *Schema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, x := range t {
+			if str, ok := x.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func hasType(v interface{}, t string) bool {
+	switch t {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	default:
+		return typeOf(v) == t
+	}
+}
+
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
@@ -0,0 +1,108 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+const productSchema = `{
+	"title": "Товар",
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"price": {"type": "integer"},
+		"currency": {"enum": ["RUB", "USD"]},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"seller": {
+			"type": ["object", "null"],
+			"properties": {"rating": {"type": "number"}},
+			"additionalProperties": false
+		}
+	},
+	"required": ["name", "price"]
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := Parse([]byte(productSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		// want lists substrings every one of which must be in the error;
+		// nil means the data is valid.
+		want []string
+	}{
+		{"valid", `{"name": "iPhone 17", "price": 89990, "currency": "RUB", "tags": ["new"], "seller": {"rating": 4.8}}`, nil},
+		{"nullable object", `{"name": "iPhone 17", "price": 89990, "seller": null}`, nil},
+		{"extra field allowed at top level", `{"name": "x", "price": 1, "url": "https://example.com"}`, nil},
+		{"missing required", `{"name": "iPhone 17"}`, []string{`$: нет обязательного поля "price"`}},
+		{"wrong type", `{"name": 17, "price": 1}`, []string{"$.name: ожидался тип string, получено number"}},
+		{"integer with fraction", `{"name": "x", "price": 1.5}`, []string{"$.price: ожидался тип integer"}},
+		{"not in enum", `{"name": "x", "price": 1, "currency": "EUR"}`, []string{"$.currency: значение EUR не входит в enum"}},
+		{"bad array item", `{"name": "x", "price": 1, "tags": ["a", 2]}`, []string{"$.tags[1]: ожидался тип string"}},
+		{"additional property", `{"name": "x", "price": 1, "seller": {"rating": 5, "id": 3}}`, []string{`$.seller: лишнее поле "id"`}},
+		{"all errors reported", `{"price": "1", "currency": "EUR"}`, []string{`нет обязательного поля "name"`, "$.price: ожидался тип integer", "$.currency"}},
+		{"not an object", `[1, 2]`, []string{"$: ожидался тип object, получено array"}},
+		{"invalid JSON", `{"name":`, []string{"invalid JSON"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.Validate([]byte(tc.data))
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want error containing %q", tc.want)
+			}
+			for _, w := range tc.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("Validate() = %q, want it to contain %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRejectsUnsupportedKeywords(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"ref", `{"$ref": "#/definitions/x"}`, `"$ref"`},
+		{"oneOf", `{"oneOf": [{"type": "string"}]}`, `"oneOf"`},
+		{"nested minimum", `{"type": "object", "properties": {"price": {"type": "number", "minimum": 0}}}`, `$.properties.price: ключевое слово "minimum"`},
+		{"pattern in items", `{"type": "array", "items": {"type": "string", "pattern": "^a"}}`, `$.items: ключевое слово "pattern"`},
+		{"minItems", `{"type": "array", "minItems": 1}`, `"minItems"`},
+		{"schema additionalProperties", `{"additionalProperties": {"type": "string"}}`, "additionalProperties поддерживается только как true/false"},
+		{"unknown type", `{"type": "date"}`, "неизвестный тип date"},
+		{"not an object", `[]`, "схема должна быть объектом"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.schema))
+			if err == nil {
+				t.Fatalf("Parse() = nil, want error containing %q", tc.want)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Parse() = %q, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseAcceptsAnnotations(t *testing.T) {
+	raw := `{"$schema": "http://json-schema.org/draft-07/schema#", "title": "t", "description": "d",
+		"type": "object", "properties": {"a": {"type": "string", "description": "x", "examples": ["y"]}}}`
+	if _, err := Parse([]byte(raw)); err != nil {
+		t.Fatalf("Parse() = %v", err)
+	}
+}